@@ -1,53 +1,61 @@
-package main
+package calculator
 
 import (
-	"image/color"
-	"strconv"
+	_ "embed"
+	"errors"
 
+	"calculator/expr"
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"github.com/Knetic/govaluate"
+	"varos/registry"
+	"varos/session"
+	"varos/ui"
 )
 
-func BoxLayout(box string) fyne.Layout {
-	layouts := map[string]fyne.Layout{
-		"H": layout.NewHBoxLayout(),
-		"V": layout.NewVBoxLayout(),
-	}
-	return layouts[box]
-}
+//go:embed manifest.json
+var manifest []byte
 
-func GridLayout(box string, n int) fyne.Layout {
-	layouts := map[string]fyne.Layout{
-		"":  layout.NewGridLayout(n),
-		"C": layout.NewGridLayoutWithColumns(n),
-		"R": layout.NewGridLayoutWithRows(n),
-	}
-	return layouts[box]
+func init() {
+	registry.MustRegister(manifest, New)
 }
 
-func Text(str string, r uint8, g uint8, b uint8, a uint8) *canvas.Text {
-	return canvas.NewText(str, color.RGBA{R: r, G: g, B: b, A: a})
-}
+// New builds the calculator inside w and returns its content
+func New(a fyne.App, w fyne.Window) fyne.CanvasObject {
 
-func main() {
-	app := app.New().NewWindow("Calculator")
+	// Saved State
+	var saved calculatorState
+	session.Restore(w, &saved)
+	history.load(a)
+	names.load(a)
+	loadRates(a)
+	// Evaluation
+	eng := newEngine(a)
+	eng.Env.Angle = saved.Angle
+	eng.RestoreAns(saved.Answer)
+	mem := newMemory(saved.Memory, saved.MemoryUsed)
+	mode := saved.Mode
+	if mode == "" {
+		mode = "Basic"
+	}
 
-	// Calculation History
-	var history []fyne.CanvasObject
+	session.Track(w, func() interface{} {
+		state := calculatorState{}
+		state.Mode = mode
+		state.Angle = eng.Env.Angle
+		state.Answer = eng.Ans()
+		state.Memory, state.MemoryUsed = mem.value, mem.used
+		state.Base, state.WordSize, state.Unsigned = eng.Prog.Base, eng.Prog.Bits, !eng.Prog.Signed
+		return state
+	})
 
 	// Fields
-	input := widget.NewEntry()
-	input.FocusGained()
+	input := newCalcEntry()
 	output := widget.NewLabel("")
-	historyItems := container.NewVBox(history...)
-	historyScroll := container.NewVScroll(historyItems)
-	historyScroll.SetMinSize(fyne.Size{Height: 100})
+	keys := newKeyboard(a, w, input)
+	historyScroll, stopHistory := historyPanel(w, &input.Entry)
 	historyScroll.Hide()
 	historyAppear := false
 
@@ -61,120 +69,300 @@ func main() {
 		}
 		historyAppear = !historyAppear
 	})
-	backBtn := widget.NewButtonWithIcon("Back", theme.NavigateBackIcon(), func() {
-		if len(input.Text) > 0 {
-			input.SetText(input.Text[:len(input.Text)-1])
+	back := func() {
+		if text := []rune(input.Text); len(text) > 0 {
+			input.SetText(string(text[:len(text)-1]))
 		}
-	})
-	evalBtn := widget.NewButton("=", func() {
-		result, err := evalExp(input.Text)
-		if err == nil {
-			history = append(history, widget.NewLabel(input.Text+" = "+result))
-			historyItems.AddObject(history[len(history)-1])
-			historyItems.Refresh()
+	}
+	backBtn := widget.NewButtonWithIcon("Back", theme.NavigateBackIcon(), back)
+	evaluate := func() {
+		result, value, assignment, err := eng.run(input.Text, true)
+		if err == nil && assignment != nil {
+			line := input.Text
+			input.SetText("")
+			if assignment.IsFunction() {
+				history.add(line, "")
+				output.SetText(result)
+			} else {
+				history.add(line, result)
+				output.SetText(assignment.Name + " = " + result)
+			}
+		} else if err == nil {
+			eng.Answer(value)
+			history.add(input.Text, result)
 			input.SetText(result)
 		} else {
-			output.SetText(result)
+			output.SetText("ERROR : " + err.Error())
+			markError(w, &input.Entry, err)
 		}
+	}
+	evalBtn := widget.NewButton("=", evaluate)
+	// Scientific Mode
+	current := func() (float64, bool) {
+		value, err := eng.value(input.Text)
+		if err != nil {
+			output.SetText("ERROR : " + err.Error())
+			markError(w, &input.Entry, err)
+			return 0, false
+		}
+		return value, true
+	}
+	scientific := scientificKeypad(a, eng, mem, func(s string) { input.SetText(input.Text + s) }, current, func() {
+		input.OnChanged(input.Text)
 	})
+
+	// Programmer Mode
+	if saved.Base != 0 {
+		eng.Prog.Base, eng.Prog.Bits = saved.Base, saved.WordSize
+	}
+	eng.Prog.Signed = !saved.Unsigned
+	programmer := newProgrammerPanel(eng.Prog, &input.Entry, func(s string) { input.SetText(input.Text + s) })
 	input.OnChanged = func(_ string) {
-		result, err := evalExp(input.Text)
-		if err == nil {
-			output.SetText(result)
+		if input.SelectedText() != "" {
+			// Drop an error mark left behind by the keypad
+			input.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEnd})
 		}
-		if input.Text == "" {
+		result, err := evalExp(eng, input.Text)
+		switch {
+		case input.Text == "":
 			output.SetText("")
+		case err == nil:
+			output.SetText(result)
+		default:
+			output.SetText(previewError(input.Text, err))
+		}
+		if mode == "Programmer" {
+			programmer.update(input.Text)
 		}
 	}
 
-	lightTheme := true
-	themeOptions := map[bool]fyne.Theme{
-		false: theme.DarkTheme(),
-		true:  theme.LightTheme()}
-	themeBtn := widget.NewButtonWithIcon("", theme.ColorPaletteIcon(), func() {
-		lightTheme = !lightTheme
-		fyne.CurrentApp().Settings().SetTheme(themeOptions[lightTheme])
+	// Conversion Mode
+	convert := convertPanel(a, w, func(s string) { input.SetText(input.Text + s) }, func() {
+		input.OnChanged(input.Text)
+	})
+
+	// Variables and Functions
+	variables, stopVariables := variablesPanel(func(s string) { input.SetText(input.Text + s) })
+	variables.Hide()
+	variablesBtn := widget.NewButton("x =", func() {
+		if variables.Visible() {
+			variables.Hide()
+		} else {
+			variables.Show()
+		}
+	})
+	stopNames := names.onChanged(func() { input.OnChanged(input.Text) })
+
+	explainBtn := widget.NewButtonWithIcon("", theme.QuestionIcon(), func() {
+		showExplain(w, eng, input.Text)
+	})
+	settingsBtn := widget.NewButtonWithIcon("", theme.SettingsIcon(), func() {
+		showSettings(a, w, eng, keys, func() { input.OnChanged(input.Text) })
 	})
 
 	// Set Buttons in Layout
 	numrows := container.New(
-		BoxLayout("V"),
+		ui.BoxLayout("V"),
 		container.New(
-			GridLayout("C", 2),
+			ui.GridLayout("C", 2),
 			historyBtn,
 			backBtn,
 		),
 		container.New(
-			GridLayout("C", 4),
+			ui.GridLayout("C", 4),
 			container.New(
-				GridLayout("R", 3),
+				ui.GridLayout("R", 3),
 				widget.NewButtonWithIcon("Clear", theme.CancelIcon(), func() { input.SetText("") }),
 				widget.NewButton("7", func() { input.SetText(input.Text + "7") }),
 				widget.NewButton("4", func() { input.SetText(input.Text + "4") }),
 			),
 			container.New(
-				GridLayout("R", 3),
+				ui.GridLayout("R", 3),
 				widget.NewButton("(", func() { input.SetText(input.Text + "(") }),
 				widget.NewButton("8", func() { input.SetText(input.Text + "8") }),
 				widget.NewButton("5", func() { input.SetText(input.Text + "5") }),
 			),
 			container.New(
-				GridLayout("R", 3),
+				ui.GridLayout("R", 3),
 				widget.NewButton(")", func() { input.SetText(input.Text + ")") }),
 				widget.NewButton("9", func() { input.SetText(input.Text + "9") }),
 				widget.NewButton("6", func() { input.SetText(input.Text + "6") }),
 			),
 			container.New(
-				GridLayout("R", 3),
+				ui.GridLayout("R", 3),
 				widget.NewButton("/", func() { input.SetText(input.Text + "/") }),
 				widget.NewButton("*", func() { input.SetText(input.Text + "*") }),
 				widget.NewButton("-", func() { input.SetText(input.Text + "-") }),
 			),
 		),
 		container.New(
-			GridLayout("C", 4),
+			ui.GridLayout("C", 4),
 			container.New(
-				GridLayout("R", 2),
+				ui.GridLayout("R", 2),
 				widget.NewButton("1", func() { input.SetText(input.Text + "1") }),
 				widget.NewButton("0", func() { input.SetText(input.Text + "0") }),
 			),
 			container.New(
-				GridLayout("R", 2),
+				ui.GridLayout("R", 2),
 				widget.NewButton("2", func() { input.SetText(input.Text + "2") }),
 				widget.NewButton(".", func() { input.SetText(input.Text + ".") }),
 			),
 			container.New(
-				GridLayout("R", 2),
+				ui.GridLayout("R", 2),
 				widget.NewButton("3", func() { input.SetText(input.Text + "3") }),
 				evalBtn,
 			),
 			container.New(
-				GridLayout("C", 1),
+				ui.GridLayout("C", 1),
 				widget.NewButton("+", func() { input.SetText(input.Text + "+") }),
 			),
 		),
 	)
+	// Graph, Matrix, Statistics and Date Modes
+	graph, stopGraph := graphPanel(eng)
+	matrices := matrixPanel(eng, func(s string) { input.SetText(input.Text + s) })
+	statistics := statisticsPanel(w, func(s string) { input.SetText(input.Text + s) })
+	date := datePanel(func(s string) { input.SetText(input.Text + s) })
+
+	modeSelect := widget.NewSelect([]string{"Basic", "Scientific", "Programmer", "Convert", "Graph", "Matrix", "Statistics", "Date"}, func(selected string) {
+		mode = selected
+		scientific.Hide()
+		programmer.content.Hide()
+		convert.Hide()
+		graph.Hide()
+		matrices.Hide()
+		statistics.Hide()
+		date.Hide()
+		numrows.Show()
+		switch mode {
+		case "Scientific":
+			scientific.Show()
+		case "Programmer":
+			programmer.content.Show()
+		case "Convert":
+			convert.Show()
+		case "Graph":
+			graph.Show()
+			numrows.Hide()
+		case "Matrix":
+			matrices.Show()
+			numrows.Hide()
+		case "Statistics":
+			statistics.Show()
+			numrows.Hide()
+		case "Date":
+			date.Show()
+		}
+		eng.Programmer = mode == "Programmer"
+		eng.Converting = mode == "Convert"
+		eng.Dates = mode == "Date"
+		input.OnChanged(input.Text)
+	})
+	modeSelect.SetSelected(mode)
+
+	// Keyboard
+	keys.handle("evaluate", evaluate)
+	keys.handle("clear", func() { input.SetText("") })
+	keys.handle("back", back)
+	keys.handle("previous", func() { keys.step(1) })
+	keys.handle("next", func() { keys.step(-1) })
+	keys.handle("history", historyBtn.OnTapped)
+	keys.handle("variables", variablesBtn.OnTapped)
+	keys.handle("explain", explainBtn.OnTapped)
+	keys.handle("mode", func() {
+		modeSelect.SetSelectedIndex((modeSelect.SelectedIndex() + 1) % len(modeSelect.Options))
+	})
 	c := container.New(
-		BoxLayout("V"),
+		ui.BoxLayout("V"),
 		input,
-		container.New(BoxLayout("H"), output, layout.NewSpacer(), themeBtn),
+		ui.Toolbar(modeSelect, variablesBtn, explainBtn, settingsBtn, output, mem.label),
 		historyScroll,
+		scientific,
+		programmer.content,
+		convert,
+		graph,
+		matrices,
+		statistics,
+		date,
 		numrows)
-	app.SetContent(c) // Add Layouts to Window
-	app.SetPadded(false)
-	app.ShowAndRun()
+	w.SetPadded(false)
+	// The stores outlive the window, stop them refreshing its widgets
+	w.SetOnClosed(func() {
+		stopHistory()
+		stopVariables()
+		stopNames()
+		stopGraph()
+	})
+	return container.NewBorder(nil, nil, nil, variables, c)
 }
 
-func evalExp(exp string) (string, error) {
-	ans, err := govaluate.NewEvaluableExpression(exp)
-	if err == nil {
-		result, e := ans.Evaluate(nil)
-		if e == nil {
-			return strconv.FormatFloat(result.(float64), 'f', -1, 64), e
-		} else {
-			return "ERROR : Expressorn Invalid", e
-		}
-	} else {
-		return "ERROR : Expressorn Invalid", err
+// calculatorState is a calculator window as kept in the VarOS session
+type calculatorState struct {
+	Mode       string
+	Angle      expr.Angle
+	Answer     string
+	Memory     float64
+	MemoryUsed bool
+	Base       int
+	WordSize   int
+	Unsigned   bool
+}
+
+func evalExp(eng *engine, exp string) (string, error) {
+	result, _, assignment, err := eng.run(exp, false)
+	if err != nil {
+		return "ERROR : " + err.Error(), err
+	}
+	if assignment != nil && !assignment.IsFunction() {
+		return assignment.Name + " = " + result, nil
+	}
+	return result, nil
+}
+
+// previewError shows an error while typing, marking the part of text it is
+// about, like 1 + ⟦*⟧ 2 : unexpected "*"
+func previewError(text string, err error) string {
+	var exprErr *expr.Error
+	if !errors.As(err, &exprErr) {
+		return "ERROR : " + err.Error()
+	}
+	runes := []rune(text)
+	start, end := exprErr.Pos, exprErr.End
+	if end > len(runes) {
+		end = len(runes)
+	}
+	if start >= len(runes) {
+		// An expression that stops too early
+		return text + " ⟦…⟧ : " + exprErr.Msg
+	}
+	return string(runes[:start]) + "⟦" + string(runes[start:end]) + "⟧" + string(runes[end:]) + " : " + exprErr.Msg
+}
+
+// markError selects the part of input an expression error points at
+func markError(w fyne.Window, input *widget.Entry, err error) {
+	var exprErr *expr.Error
+	if !errors.As(err, &exprErr) {
+		return
+	}
+	length := len([]rune(input.Text))
+	start, end := exprErr.Pos, exprErr.End
+	if start >= length {
+		start = length - 1
+	}
+	if end > length {
+		end = length
+	}
+	if start < 0 {
+		return
+	}
+
+	w.Canvas().Focus(input)
+	shift := &fyne.KeyEvent{Name: desktop.KeyShiftLeft}
+	input.TypedKey(&fyne.KeyEvent{Name: fyne.KeyHome})
+	input.CursorRow, input.CursorColumn = 0, start
+	input.KeyDown(shift)
+	for i := start; i < end; i++ {
+		input.TypedKey(&fyne.KeyEvent{Name: fyne.KeyRight})
 	}
+	input.KeyUp(shift)
 }
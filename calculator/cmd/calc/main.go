@@ -0,0 +1,312 @@
+// Command calc evaluates calculator expressions from the command line, from
+// stdin or in an interactive session, with the same engine as the calculator
+// app.
+//
+//	calc 2^10 + 1         evaluate the arguments as one expression
+//	calc -exact -1/3      the flags end where the expression starts
+//	echo "x = 3" | calc   evaluate each line of stdin
+//	calc                  start a session, :help lists its commands
+//	calc -i < script      run a script as a session
+//	calc -dates today + 90 days
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"calculator/core"
+	"calculator/expr"
+	"calculator/units"
+)
+
+func main() {
+	exact := flag.Bool("exact", false, "use exact arithmetic")
+	places := flag.Int("places", 20, "decimal places shown in exact mode")
+	angle := flag.String("angle", "rad", "angle unit of trigonometry, rad, deg or grad")
+	base := flag.Int("base", 0, "programmer mode in base 2, 8, 10 or 16")
+	bits := flag.Int("bits", 64, "word size of programmer mode")
+	unsigned := flag.Bool("unsigned", false, "unsigned integers in programmer mode")
+	convert := flag.Bool("convert", false, "conversion mode, numbers may carry units")
+	complexMode := flag.Bool("complex", false, "complex numbers, i is the imaginary unit")
+	polar := flag.Bool("polar", false, "show complex numbers in polar form, implies -complex")
+	dates := flag.Bool("dates", false, "date mode, like 2026-10-18 + 90 days")
+	rates := flag.String("rates", "", "currency rates file for conversion mode")
+	interactive := flag.Bool("i", false, "start a session even when stdin is not a terminal")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: calc [flags] [expression]")
+		fmt.Fprintln(flag.CommandLine.Output(), "The flags end at the expression, so calc -2^2+1 works, as does calc -- -2^2+1")
+		flag.PrintDefaults()
+	}
+	flag.CommandLine.Parse(expressionFirst(os.Args[1:]))
+
+	e := core.New()
+	e.Exact, e.Rational.Places = *exact, *places
+	e.Converting = *convert
+	e.Complex, e.Polar = *complexMode || *polar, *polar
+	e.Dates = *dates
+	switch *angle {
+	case "rad":
+		e.Env.Angle = expr.Radians
+	case "deg":
+		e.Env.Angle = expr.Degrees
+	case "grad":
+		e.Env.Angle = expr.Gradians
+	default:
+		fail(fmt.Errorf("unknown angle unit %q", *angle))
+	}
+	if *base != 0 {
+		if !validBase(*base) || !validBits(*bits) {
+			fail(errors.New("programmer mode needs a base of 2, 8, 10 or 16 and 8, 16, 32 or 64 bits"))
+		}
+		e.Programmer = true
+		e.Prog.Base, e.Prog.Bits, e.Prog.Signed = *base, *bits, !*unsigned
+	}
+	if *rates != "" {
+		if err := loadRates(*rates); err != nil {
+			fail(err)
+		}
+	}
+
+	s := &session{engine: e, out: os.Stdout, errs: os.Stderr}
+	if flag.NArg() > 0 {
+		if !s.run(strings.Join(flag.Args(), " ")) {
+			os.Exit(1)
+		}
+		return
+	}
+	if info, err := os.Stdin.Stat(); *interactive || err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		s.repl(os.Stdin)
+		return
+	}
+	if !s.lines(os.Stdin) {
+		os.Exit(1)
+	}
+}
+
+// expressionFirst ends the flags of args at the first argument that is not
+// one, like the -2 of -2^2+1, so that an expression may start with a minus
+func expressionFirst(args []string) []string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			return args
+		}
+		name := strings.TrimLeft(arg, "-")
+		if j := strings.Index(name, "="); j >= 0 {
+			name = name[:j]
+		}
+		f := flag.Lookup(name)
+		switch {
+		case name == "h" || name == "help":
+		case f == nil:
+			return append(append(args[:i:i], "--"), args[i:]...)
+		case !strings.Contains(arg, "="):
+			// The value of a flag that is not a bool is the next argument
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+			}
+		}
+	}
+	return args
+}
+
+func validBase(base int) bool {
+	for _, b := range expr.Bases {
+		if b == base {
+			return true
+		}
+	}
+	return false
+}
+
+func validBits(bits int) bool {
+	for _, b := range expr.WordSizes {
+		if b == bits {
+			return true
+		}
+	}
+	return false
+}
+
+func loadRates(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rates, err := units.LoadRates(f)
+	if err != nil {
+		return err
+	}
+	return units.SetRates(rates)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "calc:", err)
+	os.Exit(2)
+}
+
+// session runs lines of input, keeping the variables, functions and history
+type session struct {
+	engine  *core.Engine
+	history []string
+	out     io.Writer
+	errs    io.Writer
+}
+
+// run evaluates a line and prints its result, it returns false on an error
+func (s *session) run(line string) bool {
+	result, value, a, err := s.engine.Run(line)
+	if err != nil {
+		s.error(line, err)
+		return false
+	}
+	s.history = append(s.history, line)
+	switch {
+	case a == nil:
+		s.engine.Answer(value)
+		fmt.Fprintln(s.out, result)
+	case a.IsFunction():
+		s.engine.Assign(a, nil)
+		fmt.Fprintln(s.out, result)
+	default:
+		s.engine.Assign(a, value)
+		fmt.Fprintln(s.out, a.Name+" = "+result)
+	}
+	return true
+}
+
+// error prints err, pointing at the part of line it is about
+func (s *session) error(line string, err error) {
+	fmt.Fprintln(s.errs, "ERROR : "+err.Error())
+	var exprErr *expr.Error
+	if !errors.As(err, &exprErr) {
+		return
+	}
+	width := exprErr.End - exprErr.Pos
+	if width < 1 {
+		width = 1
+	}
+	fmt.Fprintln(s.errs, "  "+line)
+	fmt.Fprintln(s.errs, "  "+strings.Repeat(" ", exprErr.Pos)+strings.Repeat("^", width))
+}
+
+// lines runs every line of r, it returns false if any failed
+func (s *session) lines(r io.Reader) bool {
+	ok := true
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !s.run(line) {
+			ok = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(s.errs, "calc:", err)
+		return false
+	}
+	return ok
+}
+
+const help = `Type an expression to evaluate it, x = 3 to set a variable or
+f(x) = x^2 + 1 to define a function. Ans is the last result.
+  :vars       list the variables and functions
+  :history    list the lines typed
+  :explain x  show the steps evaluating x
+  !n          run line n of the history again, !! the last one
+  :help       show this help
+  :quit       leave, like Ctrl+D`
+
+// repl reads lines from r until it ends or :quit is typed
+func (s *session) repl(r io.Reader) {
+	fmt.Fprintln(s.out, "calc, :help for help")
+	scanner := bufio.NewScanner(r)
+	for fmt.Fprint(s.out, "> "); scanner.Scan(); fmt.Fprint(s.out, "> ") {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case line == ":quit" || line == ":q":
+			return
+		case line == ":help":
+			fmt.Fprintln(s.out, help)
+		case line == ":vars":
+			s.vars()
+		case strings.HasPrefix(line, ":explain "):
+			s.explain(strings.TrimSpace(strings.TrimPrefix(line, ":explain ")))
+		case line == ":history":
+			for i, h := range s.history {
+				fmt.Fprintf(s.out, "%4d  %s\n", i+1, h)
+			}
+		case strings.HasPrefix(line, "!"):
+			n := len(s.history)
+			if line != "!!" {
+				var err error
+				if n, err = strconv.Atoi(line[1:]); err != nil || n < 1 || n > len(s.history) {
+					fmt.Fprintln(s.errs, "ERROR : no line "+line[1:]+" in the history")
+					continue
+				}
+			}
+			if n == 0 {
+				fmt.Fprintln(s.errs, "ERROR : the history is empty")
+				continue
+			}
+			fmt.Fprintln(s.out, s.history[n-1])
+			s.run(s.history[n-1])
+		default:
+			s.run(line)
+		}
+	}
+	fmt.Fprintln(s.out)
+}
+
+// explain prints the steps evaluating line
+func (s *session) explain(line string) {
+	steps, err := s.engine.Explain(line)
+	for _, step := range steps {
+		fmt.Fprintln(s.out, "  "+step.Rule)
+		fmt.Fprintln(s.out, "    = "+step.Expression)
+	}
+	if err != nil {
+		s.error(line, err)
+	}
+}
+
+// vars prints the variables and functions, sorted by name
+func (s *session) vars() {
+	e := s.engine
+	var names []string
+	// Complex mode has every variable, real or not
+	for name := range e.Cplx.Vars {
+		names = append(names, name)
+	}
+	for name := range e.Env.UserFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if f, ok := e.Env.UserFuncs[name]; ok {
+			fmt.Fprintln(s.out, f.String(name))
+			continue
+		}
+		r, ok := e.Rational.Vars[name]
+		switch {
+		case !ok:
+			fmt.Fprintln(s.out, name+" = "+e.FormatComplex(e.Cplx.Vars[name]))
+		case e.Exact:
+			fmt.Fprintln(s.out, name+" = "+e.Rational.Format(r))
+		default:
+			v, _ := r.Float64()
+			fmt.Fprintln(s.out, name+" = "+expr.Format(v))
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"calculator"
+
+	"fyne.io/fyne/v2/app"
+)
+
+func main() {
+	a := app.NewWithID("io.varos.calculator")
+	w := a.NewWindow("Calculator")
+	w.SetContent(calculator.New(a, w))
+	w.ShowAndRun()
+}
@@ -0,0 +1,118 @@
+package calculator
+
+import (
+	"io/ioutil"
+
+	"calculator/units"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// ratesFile keeps the loaded currency rates in the storage of the app
+const ratesFile = "calculator-rates.json"
+
+// loadRates restores the currency rates loaded last, if any
+func loadRates(a fyne.App) {
+	reader, err := a.Storage().Open(ratesFile)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	rates, err := units.LoadRates(reader)
+	if err == nil {
+		err = units.SetRates(rates)
+	}
+	if err != nil {
+		fyne.LogError("Failed to read the currency rates", err)
+	}
+}
+
+// saveRates reads a rates file, uses it and keeps a copy for the next launch
+func saveRates(a fyne.App, reader fyne.URIReadCloser) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	rates, err := units.ParseRates(data)
+	if err != nil {
+		return err
+	}
+	if err := units.SetRates(rates); err != nil {
+		return err
+	}
+	writer, err := a.Storage().Save(ratesFile)
+	if err != nil {
+		writer, err = a.Storage().Create(ratesFile)
+		if err != nil {
+			return err
+		}
+	}
+	defer writer.Close()
+	_, err = writer.Write(data)
+	return err
+}
+
+// convertPanel returns the unit catalogue of conversion mode, tapping a unit
+// inserts it into the input
+func convertPanel(a fyne.App, w fyne.Window, insert func(string), changed func()) fyne.CanvasObject {
+	unitKeys := container.New(ui.GridLayout("C", 6))
+	ratesLabel := widget.NewLabel("")
+	showRates := func() {
+		rates := units.CurrentRates()
+		ratesLabel.SetText("Rates of " + rates.Date + " in " + rates.Base)
+	}
+	showRates()
+
+	categories := make([]string, len(units.Categories))
+	for i, c := range units.Categories {
+		categories[i] = c.Name
+	}
+	category := widget.NewSelect(categories, nil)
+	category.OnChanged = func(name string) {
+		unitKeys.Objects = nil
+		for _, unit := range units.InCategory(name) {
+			symbol := unit.Symbol
+			unitKeys.Add(widget.NewButton(symbol, func() { insert(" " + symbol) }))
+		}
+		unitKeys.Refresh()
+		if name == "Currency" {
+			ratesLabel.Show()
+		} else {
+			ratesLabel.Hide()
+		}
+	}
+	category.SetSelected(categories[0])
+
+	loadBtn := widget.NewButtonWithIcon("Load Rates", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			if err := saveRates(a, reader); err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			showRates()
+			category.OnChanged(category.Selected)
+			changed()
+		}, w)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".json"}))
+		open.Show()
+	})
+
+	return container.New(
+		ui.BoxLayout("V"),
+		container.NewHBox(category, widget.NewButton("in", func() { insert(" in ") }), loadBtn, ratesLabel),
+		unitKeys,
+	)
+}
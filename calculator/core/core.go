@@ -0,0 +1,312 @@
+// Package core evaluates calculator input the same way in every front end,
+// the Fyne calculator and the calc command. It keeps the variables, user
+// functions and Ans of a session in each arithmetic mode.
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"calculator/dates"
+	"calculator/expr"
+	"calculator/units"
+)
+
+// Engine evaluates input with float64 or, in exact mode, with rational
+// numbers. Programmer mode uses fixed size integers, conversion mode numbers
+// with units, complex mode, which wins over exact mode, complex numbers and
+// date mode dates and spans of time
+type Engine struct {
+	Env        *expr.Env
+	Rational   *expr.Exact
+	Prog       *expr.Programmer
+	Units      *units.Converter
+	Cplx       *expr.Complex
+	Exact      bool
+	Programmer bool
+	Converting bool
+	Complex    bool
+	Dates      bool
+	// Polar shows complex results as r*e^(θi) rather than a + bi
+	Polar bool
+	// Result is the last result of complex mode. Results that are not real
+	// have no rational value, Answer and Assign take them from here
+	Result complex128
+}
+
+// New returns an engine in float64 mode without any variables
+func New() *Engine {
+	env := expr.NewEnv()
+	prog := expr.NewProgrammer()
+	prog.UserFuncs = env.UserFuncs
+	return &Engine{Env: env, Rational: expr.NewExact(env), Prog: prog, Units: &units.Converter{Env: env}, Cplx: expr.NewComplex(env)}
+}
+
+// Base is the base bare numbers are typed in
+func (e *Engine) Base() int {
+	if e.Programmer {
+		return e.Prog.Base
+	}
+	return 10
+}
+
+// Evaluate returns the result of s as shown to the user and its exact value,
+// the value of a date is its Unix time
+func (e *Engine) Evaluate(s string) (string, *big.Rat, error) {
+	if e.Dates {
+		r, err := dates.Evaluate(s, time.Now())
+		if err != nil {
+			return "", nil, err
+		}
+		return r.String(), RatFromFloat(r.Value), nil
+	}
+	if e.Converting {
+		r, err := e.Units.Evaluate(s)
+		if err != nil {
+			return "", nil, err
+		}
+		return r.String(), RatFromFloat(r.Value), nil
+	}
+	n, err := expr.ParseBase(s, e.Base())
+	if err != nil {
+		return "", nil, err
+	}
+	return e.Eval(n)
+}
+
+// Eval evaluates a parsed expression
+func (e *Engine) Eval(n expr.Node) (string, *big.Rat, error) {
+	if e.Programmer {
+		v, err := e.Prog.Eval(n)
+		if err != nil {
+			return "", nil, err
+		}
+		r := new(big.Rat).SetUint64(e.Prog.Mask(v))
+		if e.Prog.Signed {
+			r.SetInt64(e.Prog.Int(v))
+		}
+		return e.Prog.Input(v), r, nil
+	}
+	if e.Converting {
+		q, err := e.Units.Eval(n)
+		if err != nil {
+			return "", nil, err
+		}
+		r := units.Display(q)
+		return r.String(), RatFromFloat(r.Value), nil
+	}
+	if e.Complex {
+		z, err := e.Cplx.Eval(n)
+		if err != nil {
+			return "", nil, err
+		}
+		e.Result = z
+		var r *big.Rat
+		if imag(z) == 0 {
+			r = RatFromFloat(real(z))
+		}
+		return e.FormatComplex(z), r, nil
+	}
+	if e.Exact {
+		r, err := e.Rational.Eval(n)
+		if err != nil {
+			return "", nil, err
+		}
+		return e.Rational.Format(r), r, nil
+	}
+	v, err := e.Env.Eval(n)
+	if err != nil {
+		return "", nil, err
+	}
+	return expr.Format(v), RatFromFloat(v), nil
+}
+
+// FormatComplex returns z in the display form of complex mode
+func (e *Engine) FormatComplex(z complex128) string {
+	if e.Polar {
+		return expr.FormatPolar(z)
+	}
+	return expr.FormatComplex(z)
+}
+
+// RatFromFloat returns v as shown by expr.Format
+func RatFromFloat(v float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(expr.Format(v))
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// Run evaluates a line of input, which may also assign a variable or define
+// a function. The assignment is returned but not kept, see Assign. The
+// result of a function definition is the definition. Date mode has no
+// assignments
+func (e *Engine) Run(s string) (string, *big.Rat, *expr.Assignment, error) {
+	if e.Dates {
+		result, value, err := e.Evaluate(s)
+		return result, value, nil, err
+	}
+	a, err := expr.ParseAssignment(s, e.Base())
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if a == nil {
+		result, value, err := e.Evaluate(s)
+		return result, value, nil, err
+	}
+	if a.IsFunction() {
+		return Func(a).String(a.Name), nil, a, nil
+	}
+	result, value, err := e.Eval(a.Body)
+	if err != nil {
+		return "", nil, a, err
+	}
+	return result, value, a, nil
+}
+
+// Explain lists the steps evaluating a line of input, see expr.Explain. The
+// steps of an assignment are those of its value
+func (e *Engine) Explain(s string) ([]expr.Step, error) {
+	if e.Dates {
+		return nil, fmt.Errorf("date mode has no steps to explain")
+	}
+	a, err := expr.ParseAssignment(s, e.Base())
+	if err != nil {
+		return nil, err
+	}
+	var n, target expr.Node
+	switch {
+	case a != nil && a.IsFunction():
+		return nil, fmt.Errorf("defining %s evaluates nothing", a.Name)
+	case a != nil:
+		n = a.Body
+	case e.Converting:
+		n, target, err = expr.ParseConversion(s)
+	default:
+		n, err = expr.ParseBase(s, e.Base())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := expr.Explain(s, n, func(n expr.Node) (string, error) {
+		result, _, err := e.Eval(n)
+		return result, err
+	})
+	if err != nil || target == nil {
+		return steps, err
+	}
+	result, _, err := e.Evaluate(s)
+	if err != nil {
+		return steps, err
+	}
+	unit := string([]rune(s)[target.Pos():target.End()])
+	return append(steps, expr.Step{Rule: "conversion to " + unit, Expression: result}), nil
+}
+
+// Func returns the function defined by a
+func Func(a *expr.Assignment) *expr.UserFunc {
+	return &expr.UserFunc{Params: a.Params, Body: a.Body, Source: a.Source}
+}
+
+// Assign keeps an assignment returned by Run, value is the value Run gave
+// a variable, nil for a complex one
+func (e *Engine) Assign(a *expr.Assignment, value *big.Rat) {
+	if a.IsFunction() {
+		e.UnsetVar(a.Name)
+		e.Env.UserFuncs[a.Name] = Func(a)
+		e.Prog.UserFuncs[a.Name] = Func(a)
+		return
+	}
+	delete(e.Env.UserFuncs, a.Name)
+	delete(e.Prog.UserFuncs, a.Name)
+	if value == nil {
+		e.SetComplexVar(a.Name, e.Result)
+		return
+	}
+	e.SetVar(a.Name, value)
+}
+
+// Value returns the result of s as a float64
+func (e *Engine) Value(s string) (float64, error) {
+	_, r, err := e.Evaluate(s)
+	if err != nil {
+		return 0, err
+	}
+	if r == nil {
+		return 0, fmt.Errorf("%s is not a real number", e.FormatComplex(e.Result))
+	}
+	v, _ := r.Float64()
+	return v, nil
+}
+
+// Answer keeps r as Ans for the next expression, nil keeps the complex
+// Result
+func (e *Engine) Answer(r *big.Rat) {
+	if r == nil {
+		e.SetComplexVar("Ans", e.Result)
+		return
+	}
+	e.SetVar("Ans", r)
+}
+
+// SetVar gives the variable name the value r in every mode
+func (e *Engine) SetVar(name string, r *big.Rat) {
+	e.Rational.Vars[name] = r
+	e.Env.Vars[name], _ = r.Float64()
+	e.Cplx.Vars[name] = complex(e.Env.Vars[name], 0)
+	if r.IsInt() && r.Num().IsInt64() {
+		e.Prog.Vars[name] = uint64(r.Num().Int64())
+	} else if r.IsInt() && r.Num().IsUint64() {
+		e.Prog.Vars[name] = r.Num().Uint64()
+	} else {
+		delete(e.Prog.Vars, name)
+	}
+}
+
+// SetComplexVar gives the variable name the value z, a variable that is not
+// real only exists in complex mode
+func (e *Engine) SetComplexVar(name string, z complex128) {
+	if imag(z) == 0 {
+		e.SetVar(name, RatFromFloat(real(z)))
+		return
+	}
+	e.UnsetVar(name)
+	e.Cplx.Vars[name] = z
+}
+
+// UnsetVar removes the variable name from every mode
+func (e *Engine) UnsetVar(name string) {
+	delete(e.Cplx.Vars, name)
+	delete(e.Rational.Vars, name)
+	delete(e.Env.Vars, name)
+	delete(e.Prog.Vars, name)
+}
+
+// Ans returns Ans as a fraction, or a + bi when it is not real, or "" before
+// the first result
+func (e *Engine) Ans() string {
+	if r, ok := e.Rational.Vars["Ans"]; ok {
+		return r.RatString()
+	}
+	if z, ok := e.Cplx.Vars["Ans"]; ok {
+		return expr.FormatComplex(z)
+	}
+	return ""
+}
+
+// RestoreAns sets Ans from a value returned by Ans
+func (e *Engine) RestoreAns(s string) {
+	if r, ok := new(big.Rat).SetString(s); ok && s != "" {
+		e.Answer(r)
+	} else if z, err := ParseComplex(s); err == nil && s != "" {
+		e.SetComplexVar("Ans", z)
+	}
+}
+
+// ParseComplex reads a number written by expr.FormatComplex
+func ParseComplex(s string) (complex128, error) {
+	return expr.NewComplex(expr.NewEnv()).Evaluate(s)
+}
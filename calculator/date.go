@@ -0,0 +1,64 @@
+package calculator
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// zones are the time zones offered by date mode, any other zone name can be
+// typed
+var zones = []string{
+	"UTC", "local",
+	"America/Los_Angeles", "America/Denver", "America/Chicago", "America/New_York", "America/Sao_Paulo",
+	"Europe/London", "Europe/Paris", "Europe/Berlin", "Europe/Moscow", "Africa/Johannesburg",
+	"Asia/Dubai", "Asia/Kolkata", "Asia/Shanghai", "Asia/Tokyo", "Australia/Sydney", "Pacific/Auckland",
+}
+
+// datePanel returns the keys of date mode, spans and time zones are
+// inserted with a space before them
+func datePanel(insert func(string)) fyne.CanvasObject {
+	key := func(label, text string) *widget.Button {
+		return widget.NewButton(label, func() { insert(text) })
+	}
+	zone := widget.NewSelect(zones, nil)
+	zone.PlaceHolder = "Time zone"
+	zone.OnChanged = func(name string) {
+		if name != "" {
+			insert(" " + name)
+			zone.ClearSelected()
+		}
+	}
+
+	return container.New(
+		ui.BoxLayout("V"),
+		container.New(
+			ui.GridLayout("C", 6),
+			widget.NewButton("Date", func() { insert(time.Now().Format("2006-01-02")) }),
+			widget.NewButton("Time", func() { insert(time.Now().Format("15:04")) }),
+			key("today", "today"),
+			key("now", "now"),
+			key(":", ":"),
+			key("@unix", "@"),
+		),
+		container.New(
+			ui.GridLayout("C", 6),
+			key("years", " years"),
+			key("months", " months"),
+			key("weeks", " weeks"),
+			key("days", " days"),
+			key("hours", " hours"),
+			key("minutes", " minutes"),
+		),
+		container.New(
+			ui.GridLayout("C", 4),
+			key("business days", " business days"),
+			key("in", " in "),
+			key("in unix", " in unix"),
+			zone,
+		),
+	)
+}
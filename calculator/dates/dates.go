@@ -0,0 +1,434 @@
+// Package dates is the evaluator of the calculator's date mode.
+//
+// A line adds spans like "90 days" or "1 year 2 months" to dates and times,
+// or subtracts two dates for the time between them, and may end with "in"
+// and a time zone, "unix" or a unit: "2026-10-18 + 90 days",
+// "2026-12-25 - today in business days",
+// "2026-10-18 14:00 Europe/Paris in America/New_York" or "@1760745600".
+// Business days are Monday to Friday, holidays are not known.
+package dates
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	// The time zones are built in for systems without a zone database
+	_ "time/tzdata"
+
+	"calculator/expr"
+)
+
+// Result is an evaluated line. Value is its number: the Unix time of a
+// date, the number of the unit asked for, or else days
+type Result struct {
+	Text  string
+	Value float64
+}
+
+// String prints r like "Sun 2026-10-18 14:00 Europe/Paris"
+func (r Result) String() string {
+	return r.Text
+}
+
+// Evaluate reads s, like "2026-10-18 + 90 days". Dates without a time zone
+// are in the zone of now, which also gives now and today
+func Evaluate(s string, now time.Time) (Result, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return Result{}, err
+	}
+	p := &parser{tokens: tokens, now: now}
+	v, err := p.line()
+	if err != nil {
+		return Result{}, err
+	}
+	return v.result(now.Location()), nil
+}
+
+type kind int
+
+const (
+	instant kind = iota
+	span
+	difference
+)
+
+// value is a date and time, a span or the difference of two dates
+type value struct {
+	kind kind
+	// t is the date, or the end of a difference
+	t time.Time
+	// from is the start of a difference
+	from time.Time
+	// dateOnly marks dates without a time of day, a difference of two
+	// of them counts calendar days
+	dateOnly bool
+	span     Span
+	// target and unit are asked for after "in"
+	target   string
+	unit     unit
+	pos, end int
+}
+
+type unit int
+
+const (
+	noUnit unit = iota
+	years
+	months
+	weeks
+	days
+	workdays
+	hours
+	minutes
+	seconds
+)
+
+var spanUnits = map[string]unit{
+	"year": years, "years": years, "yr": years, "y": years,
+	"month": months, "months": months, "mo": months,
+	"week": weeks, "weeks": weeks, "wk": weeks, "w": weeks,
+	"day": days, "days": days, "d": days,
+	"workday": workdays, "workdays": workdays,
+	"hour": hours, "hours": hours, "hr": hours, "h": hours,
+	"minute": minutes, "minutes": minutes, "min": minutes,
+	"second": seconds, "seconds": seconds, "sec": seconds, "s": seconds,
+}
+
+var unitNames = map[unit]string{
+	years: "year", months: "month", weeks: "week", days: "day",
+	workdays: "business day", hours: "hour", minutes: "minute", seconds: "second",
+}
+
+// lengths are the units of fixed length
+var lengths = map[unit]time.Duration{
+	weeks:   7 * 24 * time.Hour,
+	days:    24 * time.Hour,
+	hours:   time.Hour,
+	minutes: time.Minute,
+	seconds: time.Second,
+}
+
+// maxSpan keeps spans within about 200 years of time.Duration and 10000
+// years of calendar
+var maxSpan = map[unit]float64{
+	years: 1e4, months: 12e4, weeks: 52e4, days: 365e4, workdays: 26e5,
+	hours: 17e5, minutes: 1e8, seconds: 6e9,
+}
+
+// Span is a length of time as typed. Months, with years as 12 months, and
+// days follow the calendar, Business counts weekdays and Clock is exact
+type Span struct {
+	Months, Days, Business int
+	Clock                  time.Duration
+}
+
+// add adds n of unit u to s, parts of days become Clock
+func (s *Span) add(u unit, n float64) error {
+	whole := math.Trunc(n)
+	switch u {
+	case years, months, workdays:
+		if whole != n {
+			return fmt.Errorf("%ss must be whole", unitNames[u])
+		}
+		switch u {
+		case years:
+			s.Months += 12 * int(n)
+		case months:
+			s.Months += int(n)
+		default:
+			s.Business += int(n)
+		}
+	case weeks, days:
+		d := n * float64(lengths[u]/lengths[days])
+		s.Days += int(math.Trunc(d))
+		s.Clock += time.Duration(math.Round((d - math.Trunc(d)) * float64(lengths[days])))
+	default:
+		s.Clock += time.Duration(math.Round(n * float64(lengths[u])))
+	}
+	return nil
+}
+
+func (s Span) plus(o Span) Span {
+	return Span{s.Months + o.Months, s.Days + o.Days, s.Business + o.Business, s.Clock + o.Clock}
+}
+
+func (s Span) neg() Span {
+	return Span{-s.Months, -s.Days, -s.Business, -s.Clock}
+}
+
+// fixed is the length of a span without months or business days
+func (s Span) fixed() time.Duration {
+	return time.Duration(s.Days)*lengths[days] + s.Clock
+}
+
+// days is the length of s in days, with average months and five business
+// days to a week
+func (s Span) days() float64 {
+	return float64(s.Months)*365.2425/12 + float64(s.Business)*7/5 + s.fixed().Hours()/24
+}
+
+// String prints s like "1 year 2 months 3 days 4 hours"
+func (s Span) String() string {
+	var parts []string
+	part := func(n float64, u unit) {
+		if n != 0 {
+			parts = append(parts, count(n, u))
+		}
+	}
+	part(float64(s.Months/12), years)
+	part(float64(s.Months%12), months)
+	clock := s.Clock
+	d := clock / lengths[days]
+	clock -= d * lengths[days]
+	part(float64(s.Days+int(d)), days)
+	part(float64(s.Business), workdays)
+	h := clock / time.Hour
+	clock -= h * time.Hour
+	m := clock / time.Minute
+	clock -= m * time.Minute
+	part(float64(h), hours)
+	part(float64(m), minutes)
+	part(clock.Seconds(), seconds)
+	if len(parts) == 0 {
+		return "0 days"
+	}
+	return strings.Join(parts, " ")
+}
+
+// count prints n of a unit like "1 day" or "3 business days"
+func count(n float64, u unit) string {
+	name := unitNames[u]
+	if n != 1 {
+		name += "s"
+	}
+	return expr.Format(n) + " " + name
+}
+
+// combine adds or subtracts the operands around op
+func combine(op token, a, b value) (value, error) {
+	minus := op.text != "+"
+	if a.kind == difference {
+		a = a.asSpan()
+	}
+	if b.kind == difference {
+		b = b.asSpan()
+	}
+	switch {
+	case a.kind == instant && b.kind == instant:
+		if !minus {
+			return a, errorAt(a.pos, b.end, "dates cannot be added, subtract them for the time between")
+		}
+		return value{kind: difference, t: a.t, from: b.t, dateOnly: a.dateOnly && b.dateOnly, pos: a.pos, end: b.end}, nil
+	case a.kind == instant:
+		s := b.span
+		if minus {
+			s = s.neg()
+		}
+		a.t, a.dateOnly, a.end = shift(a.t, s), a.dateOnly && s.Clock == 0, b.end
+		return a, nil
+	case b.kind == instant:
+		if minus {
+			return a, errorAt(a.pos, b.end, "a date cannot be subtracted from a span")
+		}
+		b.t, b.dateOnly, b.pos = shift(b.t, a.span), b.dateOnly && a.span.Clock == 0, a.pos
+		return b, nil
+	}
+	if minus {
+		b.span = b.span.neg()
+	}
+	a.span, a.end = a.span.plus(b.span), b.end
+	return a, nil
+}
+
+// asSpan turns a difference into the span between its dates
+func (v value) asSpan() value {
+	if v.dateOnly {
+		return value{kind: span, span: Span{Days: v.calendarDays()}, pos: v.pos, end: v.end}
+	}
+	return value{kind: span, span: Span{Clock: v.t.Sub(v.from)}, pos: v.pos, end: v.end}
+}
+
+// shift returns t moved by s, months first
+func shift(t time.Time, s Span) time.Time {
+	if s.Months != 0 {
+		t = addMonths(t, s.Months)
+	}
+	if s.Days != 0 {
+		t = t.AddDate(0, 0, s.Days)
+	}
+	if s.Business != 0 {
+		t = addBusinessDays(t, s.Business)
+	}
+	return t.Add(s.Clock)
+}
+
+// addMonths keeps the day within the month, so January 31 and a month is
+// the last day of February
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	if last := daysIn(first.Year(), first.Month()); d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func weekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// addBusinessDays moves t by n weekdays, a weekend counts as the weekday
+// before it going forward and after it going back
+func addBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step = -1
+	}
+	for weekend(t) {
+		t = t.AddDate(0, 0, -step)
+	}
+	t = t.AddDate(0, 0, n/5*7)
+	for n %= 5; n != 0; {
+		t = t.AddDate(0, 0, step)
+		if !weekend(t) {
+			n -= step
+		}
+	}
+	return t
+}
+
+// dayNumber counts the days of the calendar date of t since 1970-01-01
+func dayNumber(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// calendarDays is the number of days between the dates of a difference
+func (v value) calendarDays() int {
+	return dayNumber(v.t) - dayNumber(v.from)
+}
+
+// businessDays counts the weekdays from the first date up to the second
+func (v value) businessDays() int {
+	from, to, sign := dayNumber(v.from), dayNumber(v.t), 1
+	if to < from {
+		from, to, sign = to, from, -1
+	}
+	n := (to - from) / 7 * 5
+	// 1970-01-01 was a Thursday
+	for d := from + (to-from)/7*7; d < to; d++ {
+		if wd := (d%7 + 7 + 4) % 7; wd != 0 && wd != 6 {
+			n++
+		}
+	}
+	return sign * n
+}
+
+// monthsBetween returns the whole months of a difference and the date they
+// reach
+func (v value) monthsBetween() (int, time.Time) {
+	n := (v.t.Year()-v.from.Year())*12 + int(v.t.Month()-v.from.Month())
+	if v.t.After(v.from) {
+		for n > 0 && addMonths(v.from, n).After(v.t) {
+			n--
+		}
+	} else {
+		for n < 0 && addMonths(v.from, n).Before(v.t) {
+			n++
+		}
+	}
+	return n, addMonths(v.from, n)
+}
+
+// result prints v, dates in the zone local have no zone name
+func (v value) result(local *time.Location) Result {
+	switch v.kind {
+	case instant:
+		unix := float64(v.t.Unix()) + float64(v.t.Nanosecond())/1e9
+		if v.target == "unix" {
+			return Result{"@" + expr.Format(unix), unix}
+		}
+		return Result{formatTime(v.t, v.dateOnly, local), unix}
+	case span:
+		if v.unit == noUnit {
+			return Result{v.span.String(), v.span.days()}
+		}
+		return inUnit(v.span.fixed(), v.unit)
+	}
+
+	switch v.unit {
+	case noUnit:
+		if v.dateOnly {
+			n := float64(v.calendarDays())
+			return Result{count(n, days), n}
+		}
+		d := v.t.Sub(v.from)
+		return Result{Span{Clock: d}.String(), d.Hours() / 24}
+	case workdays:
+		n := float64(v.businessDays())
+		return Result{count(n, workdays), n}
+	case years, months:
+		n, reached := v.monthsBetween()
+		rest := value{kind: difference, t: v.t, from: reached, dateOnly: v.dateOnly}.asSpan().span
+		var parts []string
+		number := float64(n)
+		if v.unit == years {
+			number /= 12
+			if n != 0 {
+				parts = append(parts, Span{Months: n}.String())
+			}
+		} else if n != 0 {
+			parts = append(parts, count(number, months))
+		}
+		if rest != (Span{}) || n == 0 {
+			parts = append(parts, rest.String())
+		}
+		return Result{strings.Join(parts, " "), number}
+	}
+	if v.dateOnly {
+		return inUnit(time.Duration(v.calendarDays())*lengths[days], v.unit)
+	}
+	return inUnit(v.t.Sub(v.from), v.unit)
+}
+
+// inUnit prints d in a unit of fixed length, whole days in weeks and days
+func inUnit(d time.Duration, u unit) Result {
+	n := float64(d) / float64(lengths[u])
+	if u == weeks && d%lengths[days] == 0 && d%lengths[weeks] != 0 {
+		w := d / lengths[weeks]
+		text := count(float64(d%lengths[weeks]/lengths[days]), days)
+		if w != 0 {
+			text = count(float64(w), weeks) + " " + text
+		}
+		return Result{text, n}
+	}
+	return Result{count(n, u), n}
+}
+
+// formatTime prints t like "Sun 2026-10-18 14:00 Europe/Paris"
+func formatTime(t time.Time, dateOnly bool, local *time.Location) string {
+	s := t.Format("Mon 2006-01-02")
+	if !dateOnly {
+		if t.Second() != 0 {
+			s += t.Format(" 15:04:05")
+		} else {
+			s += t.Format(" 15:04")
+		}
+	}
+	switch loc := t.Location(); {
+	case loc == local:
+		return s
+	case loc == time.UTC:
+		return s + " UTC"
+	case loc.String() == "":
+		return s + t.Format(" -07:00")
+	default:
+		return s + " " + loc.String()
+	}
+}
@@ -0,0 +1,73 @@
+package dates
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"calculator/expr"
+)
+
+// now is the time the tests are evaluated at
+func now(t *testing.T) time.Time {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return time.Date(2026, 10, 18, 14, 3, 12, 0, paris)
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"2026-10-18 + 90 days", "Sat 2027-01-16"},
+		{"2026-12-25 - 2026-10-18", "68 days"},
+		{"2026-12-25 - 2026-10-18 in weeks", "9 weeks 5 days"},
+		{"2026-12-25 - 2026-10-18 in business days", "49 business days"},
+		{"2026-01-31 + 1 month", "Sat 2026-02-28"},
+		{"2024-02-29 + 1 year", "Fri 2025-02-28"},
+		{"2026-10-16 + 1 business days", "Mon 2026-10-19"},
+		{"2026-10-18 14:00 Europe/Paris in America/New_York", "Sun 2026-10-18 08:00 America/New_York"},
+		{"@1760745600", "Sat 2025-10-18 00:00 UTC"},
+		{"2026-10-18 in unix", "@1792274400"},
+		{"today + 1 week", "Sun 2026-10-25"},
+	}
+	for _, test := range tests {
+		r, err := Evaluate(test.in, now(t))
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if r.String() != test.want {
+			t.Errorf("%s = %s, want %s", test.in, r, test.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		in       string
+		pos, end int
+		msg      string
+	}{
+		{"2026-10-18 + 2026-10-19", 0, 23, "dates cannot be added, subtract them for the time between"},
+		{"90 days - 2026-10-18", 0, 20, "a date cannot be subtracted from a span"},
+		{"2026-13-01", 0, 10, "2026-13-01 is not a date"},
+		{"2026-10-18 + 3 fortnights", 15, 25, `unknown unit "fortnights"`},
+		{"2026-10-18 in Mars/Olympus", 14, 26, `unknown time zone "Mars/Olympus"`},
+		{"90 days in months", 11, 17, "a span can only be shown in months between two dates"},
+		{"2026-10-18 in", 13, 14, "missing a time zone or a unit after in"},
+	}
+	for _, test := range tests {
+		_, err := Evaluate(test.in, now(t))
+		var e *expr.Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *expr.Error", test.in, err)
+			continue
+		}
+		if e.Pos != test.pos || e.End != test.end || e.Msg != test.msg {
+			t.Errorf("%s: %q at %d-%d, want %q at %d-%d", test.in, e.Msg, e.Pos, e.End, test.msg, test.pos, test.end)
+		}
+	}
+}
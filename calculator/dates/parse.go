@@ -0,0 +1,389 @@
+package dates
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"calculator/expr"
+)
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tDate
+	tClock
+	tNumber
+	tWord
+	tOp
+	tAt
+	tOffset
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	pos, end int
+}
+
+var (
+	datePattern   = regexp.MustCompile(`^\d{4}-\d{1,2}-\d{1,2}(T\d{1,2}:\d{2}(:\d{2}(\.\d+)?)?)?`)
+	clockPattern  = regexp.MustCompile(`^\d{1,2}:\d{2}(:\d{2}(\.\d+)?)?`)
+	numberPattern = regexp.MustCompile(`^\d+(\.\d+)?`)
+	offsetPattern = regexp.MustCompile(`^[+-]\d{2}:\d{2}`)
+)
+
+// lex splits s into tokens, positions count runes like expr.Error. The
+// patterns only match ASCII so their byte lengths are rune lengths
+func lex(s string) ([]token, error) {
+	runes := []rune(s)
+	var tokens []token
+	add := func(kind tokenKind, pos, end int) {
+		tokens = append(tokens, token{kind, string(runes[pos:end]), pos, end})
+	}
+	for i := 0; ; {
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+		if i == len(runes) {
+			tokens = append(tokens, token{kind: tEOF, pos: i, end: i})
+			return tokens, nil
+		}
+		rest := string(runes[i:])
+		c := runes[i]
+		start := i
+		switch {
+		case c >= '0' && c <= '9':
+			if n := len(datePattern.FindString(rest)); n > 0 {
+				i += n
+				add(tDate, start, i)
+			} else if n := len(clockPattern.FindString(rest)); n > 0 {
+				i += n
+				add(tClock, start, i)
+			} else {
+				i += len(numberPattern.FindString(rest))
+				add(tNumber, start, i)
+			}
+		case (c == '+' || c == '-') && offsetPattern.MatchString(rest) && len(tokens) > 0 &&
+			(tokens[len(tokens)-1].kind == tDate || tokens[len(tokens)-1].kind == tClock):
+			// +02:00 right after a time is its offset, + 2 days is a sum
+			i += 6
+			add(tOffset, start, i)
+		case c == '+' || c == '-' || c == '−':
+			i++
+			add(tOp, start, i)
+		case c == '@':
+			i++
+			add(tAt, start, i)
+		case unicode.IsLetter(c):
+			// Zone names like America/Port-au-Prince or Etc/GMT+5
+			zone := false
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '_' || runes[i] == '/' ||
+				zone && (unicode.IsDigit(runes[i]) || runes[i] == '-' || runes[i] == '+')) {
+				zone = zone || runes[i] == '/'
+				i++
+			}
+			add(tWord, start, i)
+		default:
+			return nil, errorAt(start, start+1, "unexpected %q", string(c))
+		}
+	}
+}
+
+func errorAt(pos, end int, format string, args ...interface{}) *expr.Error {
+	if end <= pos {
+		end = pos + 1
+	}
+	return &expr.Error{Pos: pos, End: end, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parser evaluates the tokens of a line as it reads them
+type parser struct {
+	tokens []token
+	i      int
+	now    time.Time
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tEOF {
+		p.i++
+	}
+	return t
+}
+
+// word reports whether the next token is one of words
+func (p *parser) word(words ...string) bool {
+	t := p.peek()
+	if t.kind != tWord {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// line is sum [("in" | "to") target]
+func (p *parser) line() (value, error) {
+	v, err := p.sum()
+	if err != nil {
+		return v, err
+	}
+	if p.word("in", "to") {
+		p.next()
+		if v, err = p.convert(v); err != nil {
+			return v, err
+		}
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return v, errorAt(t.pos, t.end, "unexpected %q", t.text)
+	}
+	return v, nil
+}
+
+// sum is operand {("+" | "-") operand}
+func (p *parser) sum() (value, error) {
+	v, err := p.operand()
+	if err != nil {
+		return v, err
+	}
+	for p.peek().kind == tOp {
+		op := p.next()
+		w, err := p.operand()
+		if err != nil {
+			return v, err
+		}
+		if v, err = combine(op, v, w); err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+// operand is a date, a time, now, today, a Unix time or a span
+func (p *parser) operand() (value, error) {
+	if p.word(weekdays...) {
+		// Results start with the weekday, it is only a reminder
+		day := p.next()
+		if p.peek().kind != tDate {
+			return value{}, errorAt(day.pos, day.end, "%s needs a date after it", day.text)
+		}
+	}
+	t := p.peek()
+	switch t.kind {
+	case tDate, tClock:
+		return p.instant()
+	case tAt:
+		p.next()
+		return p.unix(t)
+	case tNumber:
+		return p.span()
+	case tOp:
+		// A negative span, as in the results of differences back in time
+		p.next()
+		v, err := p.operand()
+		if err == nil && v.kind != span {
+			return v, errorAt(t.pos, v.end, "only a span can be negative")
+		}
+		if t.text != "+" {
+			v.span = v.span.neg()
+		}
+		v.pos = t.pos
+		return v, err
+	case tWord:
+		p.next()
+		today := time.Date(p.now.Year(), p.now.Month(), p.now.Day(), 0, 0, 0, 0, p.now.Location())
+		switch strings.ToLower(t.text) {
+		case "now":
+			return value{kind: instant, t: p.now, pos: t.pos, end: t.end}, nil
+		case "today":
+			return value{kind: instant, t: today, dateOnly: true, pos: t.pos, end: t.end}, nil
+		case "tomorrow":
+			return value{kind: instant, t: today.AddDate(0, 0, 1), dateOnly: true, pos: t.pos, end: t.end}, nil
+		case "yesterday":
+			return value{kind: instant, t: today.AddDate(0, 0, -1), dateOnly: true, pos: t.pos, end: t.end}, nil
+		case "unix":
+			return p.unix(t)
+		}
+		return value{}, errorAt(t.pos, t.end, "unknown word %q", t.text)
+	case tEOF:
+		return value{}, errorAt(t.pos, t.end, "missing a date or a span")
+	}
+	return value{}, errorAt(t.pos, t.end, "unexpected %q", t.text)
+}
+
+// instant reads a date with an optional time and zone, or a time of today
+func (p *parser) instant() (value, error) {
+	first := p.next()
+	v := value{kind: instant, pos: first.pos, end: first.end}
+	y, m, d := p.now.Date()
+	clock := first.text
+	if first.kind == tDate {
+		parts := strings.SplitN(first.text, "T", 2)
+		fields := strings.Split(parts[0], "-")
+		y, _ = strconv.Atoi(fields[0])
+		month, _ := strconv.Atoi(fields[1])
+		d, _ = strconv.Atoi(fields[2])
+		m = time.Month(month)
+		if month < 1 || month > 12 || d < 1 || d > daysIn(y, m) {
+			return v, errorAt(first.pos, first.end, "%s is not a date", parts[0])
+		}
+		clock = ""
+		if len(parts) == 2 {
+			clock = parts[1]
+		} else if p.peek().kind == tClock {
+			t := p.next()
+			clock, v.end = t.text, t.end
+		}
+	}
+
+	var h, min, sec, nsec int
+	v.dateOnly = clock == ""
+	if clock != "" {
+		fields := strings.Split(clock, ":")
+		h, _ = strconv.Atoi(fields[0])
+		min, _ = strconv.Atoi(fields[1])
+		if len(fields) == 3 {
+			s, _ := strconv.ParseFloat(fields[2], 64)
+			sec = int(s)
+			nsec = int(math.Round((s - float64(sec)) * 1e9))
+		}
+		if h > 23 || min > 59 || sec > 59 {
+			return v, errorAt(v.pos, v.end, "%s is not a time of day", clock)
+		}
+	}
+
+	loc := p.now.Location()
+	if t := p.peek(); t.kind == tOffset {
+		p.next()
+		hours, _ := strconv.Atoi(t.text[1:3])
+		minutes, _ := strconv.Atoi(t.text[4:6])
+		offset := (hours*60 + minutes) * 60
+		if t.text[0] == '-' {
+			offset = -offset
+		}
+		loc, v.end = time.FixedZone("", offset), t.end
+	} else if t.kind == tWord && !p.word("in", "to") && !p.word(weekdays...) {
+		p.next()
+		zone, err := p.location(t)
+		if err != nil {
+			return v, err
+		}
+		loc, v.end = zone, t.end
+	}
+	v.t = time.Date(y, m, d, h, min, sec, nsec, loc)
+	return v, nil
+}
+
+// unix reads the seconds after @ or unix
+func (p *parser) unix(before token) (value, error) {
+	t := p.next()
+	if t.kind != tNumber {
+		return value{}, errorAt(before.pos, t.end, "%s needs a number of seconds", before.text)
+	}
+	seconds, _ := strconv.ParseFloat(t.text, 64)
+	if seconds > maxUnix {
+		return value{}, errorAt(t.pos, t.end, "%s seconds is too far in the future", t.text)
+	}
+	whole := math.Floor(seconds)
+	at := time.Unix(int64(whole), int64(math.Round((seconds-whole)*1e9))).UTC()
+	return value{kind: instant, t: at, pos: before.pos, end: t.end}, nil
+}
+
+// maxUnix keeps Unix times within the years time.Time can show
+const maxUnix = 1e12
+
+// span is number unit {number unit}
+func (p *parser) span() (value, error) {
+	v := value{kind: span, pos: p.peek().pos}
+	for p.peek().kind == tNumber {
+		number := p.next()
+		n, _ := strconv.ParseFloat(number.text, 64)
+		unit := p.next()
+		name := strings.ToLower(unit.text)
+		if unit.kind == tWord && (name == "business" || name == "working") && p.word("day", "days") {
+			name, unit.end = "workdays", p.next().end
+		}
+		if unit.kind != tWord {
+			return v, errorAt(number.pos, number.end, "%s needs a unit like days", number.text)
+		}
+		u, ok := spanUnits[name]
+		if !ok {
+			return v, errorAt(unit.pos, unit.end, "unknown unit %q", unit.text)
+		}
+		if n > maxSpan[u] {
+			return v, errorAt(number.pos, unit.end, "%s %s is too long", number.text, unit.text)
+		}
+		if err := v.span.add(u, n); err != nil {
+			return v, errorAt(number.pos, unit.end, "%v", err)
+		}
+		v.end = unit.end
+	}
+	return v, nil
+}
+
+// convert shows v after "in" in a time zone, as a Unix time or in a unit
+func (p *parser) convert(v value) (value, error) {
+	t := p.next()
+	if t.kind != tWord {
+		return v, errorAt(t.pos, t.end, "missing a time zone or a unit after in")
+	}
+	name := strings.ToLower(t.text)
+	if (name == "business" || name == "working") && p.word("day", "days") {
+		name, t.end = "workdays", p.next().end
+	}
+	u, isUnit := spanUnits[name]
+	switch {
+	case v.kind == instant && name == "unix":
+		v.target = "unix"
+	case v.kind == instant && !isUnit:
+		loc, err := p.location(t)
+		if err != nil {
+			return v, err
+		}
+		v.t, v.dateOnly = v.t.In(loc), false
+	case v.kind == instant:
+		return v, errorAt(t.pos, t.end, "a date can be shown in a time zone or as unix")
+	case !isUnit:
+		return v, errorAt(t.pos, t.end, "a span can be shown in years, months, weeks, days, business days, hours, minutes or seconds")
+	case v.kind == span && (u == years || u == months || u == workdays):
+		return v, errorAt(t.pos, t.end, "a span can only be shown in %s between two dates", t.text)
+	case v.kind == span && (v.span.Months != 0 || v.span.Business != 0):
+		return v, errorAt(v.pos, v.end, "months and business days have no fixed length, add them to a date first")
+	default:
+		v.unit = u
+	}
+	return v, nil
+}
+
+// location returns the time zone named by t, local is the zone of now
+func (p *parser) location(t token) (*time.Location, error) {
+	switch strings.ToLower(t.text) {
+	case "utc", "gmt", "z":
+		return time.UTC, nil
+	case "local":
+		return p.now.Location(), nil
+	}
+	loc, err := time.LoadLocation(t.text)
+	if err != nil {
+		return nil, errorAt(t.pos, t.end, "unknown time zone %q", t.text)
+	}
+	return loc, nil
+}
+
+var weekdays = []string{
+	"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun",
+	"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday",
+}
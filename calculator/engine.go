@@ -0,0 +1,80 @@
+package calculator
+
+import (
+	"math/big"
+
+	"calculator/core"
+	"calculator/expr"
+	"fyne.io/fyne/v2"
+)
+
+// Preferences of the exact and complex modes, shared by every calculator
+// window
+const (
+	exactPref     = "calculator.exact"
+	precisionPref = "calculator.precision"
+	placesPref    = "calculator.places"
+	roundingPref  = "calculator.rounding"
+	complexPref   = "calculator.complex"
+	polarPref     = "calculator.polar"
+)
+
+// engine evaluates the input of a calculator window with the variables and
+// functions kept in names
+type engine struct {
+	*core.Engine
+}
+
+func newEngine(a fyne.App) *engine {
+	e := &engine{core.New()}
+	e.load(a)
+	return e
+}
+
+// load reads the exact and complex mode settings from the preferences
+func (e *engine) load(a fyne.App) {
+	prefs := a.Preferences()
+	e.Exact = prefs.Bool(exactPref)
+	e.Rational.Prec = uint(prefs.IntWithFallback(precisionPref, int(e.Rational.Prec)))
+	e.Rational.Places = prefs.IntWithFallback(placesPref, e.Rational.Places)
+	e.Rational.Rounding = expr.Rounding(prefs.IntWithFallback(roundingPref, int(e.Rational.Rounding)))
+	e.Complex = prefs.Bool(complexPref)
+	e.Polar = prefs.Bool(polarPref)
+}
+
+// save writes the exact and complex mode settings to the preferences
+func (e *engine) save(a fyne.App) {
+	prefs := a.Preferences()
+	prefs.SetBool(exactPref, e.Exact)
+	prefs.SetInt(precisionPref, int(e.Rational.Prec))
+	prefs.SetInt(placesPref, e.Rational.Places)
+	prefs.SetInt(roundingPref, int(e.Rational.Rounding))
+	prefs.SetBool(complexPref, e.Complex)
+	prefs.SetBool(polarPref, e.Polar)
+}
+
+// run evaluates a line of input, which may also assign a variable or define
+// a function. The assignment is returned, and only kept in names when commit
+// is set
+func (e *engine) run(s string, commit bool) (string, *big.Rat, *expr.Assignment, error) {
+	names.apply(e)
+	result, value, a, err := e.Run(s)
+	if err != nil || a == nil || !commit {
+		return result, value, a, err
+	}
+	switch {
+	case a.IsFunction():
+		names.define(a.Name, core.Func(a), e.Base())
+	case value == nil:
+		names.setComplex(a.Name, e.Result)
+	default:
+		names.set(a.Name, value)
+	}
+	return result, value, a, nil
+}
+
+// value returns the result of s as a float64
+func (e *engine) value(s string) (float64, error) {
+	names.apply(e)
+	return e.Value(s)
+}
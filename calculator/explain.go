@@ -0,0 +1,34 @@
+package calculator
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// showExplain opens the steps evaluating line, one reduction at a time
+func showExplain(w fyne.Window, e *engine, line string) {
+	if line == "" {
+		return
+	}
+	names.apply(e)
+	steps, err := e.Explain(line)
+
+	list := container.New(ui.BoxLayout("V"), widget.NewLabelWithStyle(line, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
+	for _, step := range steps {
+		list.Add(widget.NewLabelWithStyle(step.Rule, fyne.TextAlignLeading, fyne.TextStyle{Italic: true}))
+		list.Add(widget.NewLabelWithStyle("= "+step.Expression, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true}))
+	}
+	switch {
+	case err != nil:
+		list.Add(widget.NewLabel("ERROR : " + err.Error()))
+	case len(steps) == 0:
+		list.Add(widget.NewLabel("Nothing to work out, this is already a value"))
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(420, 320))
+	dialog.ShowCustom("Explain", "Close", scroll, w)
+}
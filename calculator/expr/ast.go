@@ -0,0 +1,68 @@
+package expr
+
+// Node is an element of a parsed expression, Pos and End are the rune offsets
+// of the input it was read from
+type Node interface {
+	Pos() int
+	End() int
+}
+
+// Num is a number literal
+type Num struct {
+	Value float64
+	Text  string
+	Start int
+	Stop  int
+}
+
+// Ident is a reference to a constant or variable
+type Ident struct {
+	Name  string
+	Start int
+	Stop  int
+}
+
+// Call is a function call like sin(x)
+type Call struct {
+	Name  string
+	Args  []Node
+	Start int
+	Stop  int
+}
+
+// Unary is a prefix sign (+, -) or the postfix factorial (!)
+type Unary struct {
+	Op    string
+	X     Node
+	Start int
+	Stop  int
+}
+
+// Binary is an infix operation, Implicit is set for multiplication written
+// without an operator like 2pi
+type Binary struct {
+	Op       string
+	X, Y     Node
+	OpPos    int
+	Implicit bool
+}
+
+// Paren is an expression in parentheses
+type Paren struct {
+	X     Node
+	Start int
+	Stop  int
+}
+
+func (n *Num) Pos() int    { return n.Start }
+func (n *Num) End() int    { return n.Stop }
+func (n *Ident) Pos() int  { return n.Start }
+func (n *Ident) End() int  { return n.Stop }
+func (n *Call) Pos() int   { return n.Start }
+func (n *Call) End() int   { return n.Stop }
+func (n *Unary) Pos() int  { return n.Start }
+func (n *Unary) End() int  { return n.Stop }
+func (n *Binary) Pos() int { return n.X.Pos() }
+func (n *Binary) End() int { return n.Y.End() }
+func (n *Paren) Pos() int  { return n.Start }
+func (n *Paren) End() int  { return n.Stop }
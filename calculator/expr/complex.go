@@ -0,0 +1,333 @@
+package expr
+
+import (
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+// I is the imaginary unit, written i
+const I = "i"
+
+// Complex evaluates expressions with complex numbers, 3 + 4i or sqrt(-1).
+// Functions without a complex version are left to Env when their arguments
+// are real
+type Complex struct {
+	Env   *Env
+	Vars  map[string]complex128
+	depth int
+}
+
+// NewComplex returns a complex evaluator using the functions, user
+// functions and angle unit of env
+func NewComplex(env *Env) *Complex {
+	return &Complex{Env: env, Vars: make(map[string]complex128)}
+}
+
+// Evaluate parses and evaluates s
+func (c *Complex) Evaluate(s string) (complex128, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return c.Eval(n)
+}
+
+// Eval evaluates a parsed expression
+func (c *Complex) Eval(n Node) (complex128, error) {
+	z, err := c.eval(n)
+	if err == nil && (cmplx.IsNaN(z) || cmplx.IsInf(z)) {
+		return 0, errorAt(n.Pos(), n.End(), "result is not a finite number")
+	}
+	// Adding 0 turns -0 into 0, or sqrt(-1) would be -i past the branch cut
+	return complex(real(z)+0, imag(z)+0), err
+}
+
+func (c *Complex) eval(n Node) (complex128, error) {
+	switch n := n.(type) {
+	case *Num:
+		v, err := c.Env.Eval(n)
+		return complex(v, 0), err
+	case *Paren:
+		return c.Eval(n.X)
+	case *Ident:
+		if z, ok := c.Vars[n.Name]; ok {
+			return z, nil
+		}
+		if n.Name == I {
+			return 1i, nil
+		}
+		v, err := c.Env.Eval(n)
+		return complex(v, 0), err
+	case *Unary:
+		z, err := c.Eval(n.X)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case "-":
+			return -z, nil
+		case "~", "!":
+			x, err := c.real(z, n.X, n.Op)
+			if err != nil {
+				return 0, err
+			}
+			v, err := c.Env.Eval(&Unary{Op: n.Op, X: &Num{Value: x, Start: n.X.Pos(), Stop: n.X.End()}, Start: n.Start, Stop: n.Stop})
+			return complex(v, 0), err
+		}
+		return z, nil
+	case *Binary:
+		return c.binary(n)
+	case *Call:
+		return c.call(n)
+	}
+	return 0, errorAt(n.Pos(), n.End(), "cannot evaluate")
+}
+
+// real returns the real part of z, failing if z is not real
+func (c *Complex) real(z complex128, n Node, what string) (float64, error) {
+	if imag(z) != 0 {
+		return 0, errorAt(n.Pos(), n.End(), "%s needs a real number", what)
+	}
+	return real(z), nil
+}
+
+func (c *Complex) binary(n *Binary) (complex128, error) {
+	a, err := c.Eval(n.X)
+	if err != nil {
+		return 0, err
+	}
+	b, err := c.Eval(n.Y)
+	if err != nil {
+		return 0, err
+	}
+	switch n.Op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return 0, errorAt(n.Y.Pos(), n.Y.End(), "division by zero")
+		}
+		return a / b, nil
+	case "^":
+		return complexPow(n, a, b)
+	}
+	// The rest, % and the bitwise operators, only work on real numbers
+	x, err := c.real(a, n.X, n.Op)
+	if err != nil {
+		return 0, err
+	}
+	y, err := c.real(b, n.Y, n.Op)
+	if err != nil {
+		return 0, err
+	}
+	v, err := c.Env.binary(&Binary{Op: n.Op, X: &Num{Value: x, Start: n.X.Pos(), Stop: n.X.End()}, Y: &Num{Value: y, Start: n.Y.Pos(), Stop: n.Y.End()}, OpPos: n.OpPos})
+	return complex(v, 0), err
+}
+
+// complexPow returns a^b, by repeated multiplication for small whole b so
+// that i^2 is exactly -1
+func complexPow(n *Binary, a, b complex128) (complex128, error) {
+	if imag(b) == 0 && real(b) == math.Trunc(real(b)) && math.Abs(real(b)) <= maxExponent {
+		e := int64(real(b))
+		if e < 0 && a == 0 {
+			return 0, errorAt(n.X.Pos(), n.Y.End(), "division by zero")
+		}
+		z, base := complex(1, 0), a
+		for k := abs64(e); k > 0; k >>= 1 {
+			if k&1 == 1 {
+				z *= base
+			}
+			base *= base
+		}
+		if e < 0 {
+			z = 1 / z
+		}
+		return z, nil
+	}
+	if a == 0 && real(b) <= 0 {
+		return 0, errorAt(n.X.Pos(), n.Y.End(), "0 ^ %s is not defined", FormatComplex(b))
+	}
+	return cmplx.Pow(a, b), nil
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// complexFunctions are the functions with a complex version, with the flags
+// of Functions for angles
+var complexFunctions = map[string]func(z complex128) complex128{
+	"sin":   cmplx.Sin,
+	"cos":   cmplx.Cos,
+	"tan":   cmplx.Tan,
+	"asin":  cmplx.Asin,
+	"acos":  cmplx.Acos,
+	"atan":  cmplx.Atan,
+	"sinh":  cmplx.Sinh,
+	"cosh":  cmplx.Cosh,
+	"tanh":  cmplx.Tanh,
+	"asinh": cmplx.Asinh,
+	"acosh": cmplx.Acosh,
+	"atanh": cmplx.Atanh,
+	"sqrt":  cmplx.Sqrt,
+	"exp":   cmplx.Exp,
+	"ln":    cmplx.Log,
+	"log2":  func(z complex128) complex128 { return cmplx.Log(z) / math.Ln2 },
+	"abs":   func(z complex128) complex128 { return complex(cmplx.Abs(z), 0) },
+	"arg":   func(z complex128) complex128 { return complex(cmplx.Phase(z), 0) },
+	"conj":  cmplx.Conj,
+	"re":    func(z complex128) complex128 { return complex(real(z), 0) },
+	"im":    func(z complex128) complex128 { return complex(imag(z), 0) },
+}
+
+func (c *Complex) call(n *Call) (complex128, error) {
+	if uf, ok := c.Env.UserFuncs[n.Name]; ok {
+		return c.callUser(n, uf)
+	}
+	f, ok := c.Env.Funcs[n.Name]
+	if !ok || len(n.Args) < f.MinArgs || len(n.Args) > f.MaxArgs {
+		if _, isVar := c.Vars[n.Name]; (isVar || n.Name == I) && len(n.Args) == 1 {
+			// i(2) is i times 2
+			return c.Eval(&Binary{Op: "*", X: &Ident{Name: n.Name, Start: n.Start, Stop: n.Start + len([]rune(n.Name))}, Y: n.Args[0], OpPos: n.Start, Implicit: true})
+		}
+		// Let Env report the error or multiply a name by its argument
+		v, err := c.Env.Eval(n)
+		return complex(v, 0), err
+	}
+	args := make([]complex128, len(n.Args))
+	for i, a := range n.Args {
+		z, err := c.Eval(a)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = z
+	}
+
+	if n.Name == "log" {
+		if args[0] == 0 {
+			return 0, errorAt(n.Start, n.Stop, "log: argument must not be 0")
+		}
+		if len(args) == 1 {
+			return cmplx.Log10(args[0]), nil
+		}
+		if args[1] == 0 || args[1] == 1 {
+			return 0, errorAt(n.Start, n.Stop, "log: base must not be 0 or 1")
+		}
+		return cmplx.Log(args[0]) / cmplx.Log(args[1]), nil
+	}
+	if g, ok := complexFunctions[n.Name]; ok {
+		z := args[0]
+		if (n.Name == "ln" || n.Name == "log2") && z == 0 {
+			return 0, errorAt(n.Start, n.Stop, "%s: argument must not be 0", n.Name)
+		}
+		if f.AngleArgs {
+			z *= complex(c.Env.Angle.toRadians(1), 0)
+		}
+		z = g(z)
+		if f.AngleResult {
+			z *= complex(c.Env.Angle.fromRadians(1), 0)
+		}
+		return z, nil
+	}
+
+	floats := make([]float64, len(args))
+	for i, z := range args {
+		x, err := c.real(z, n.Args[i], n.Name)
+		if err != nil {
+			return 0, err
+		}
+		floats[i] = x
+	}
+	v, err := c.Env.Apply(n, f, floats)
+	return complex(v, 0), err
+}
+
+// callUser evaluates the body of a user function with its parameters set
+func (c *Complex) callUser(n *Call, f *UserFunc) (complex128, error) {
+	if err := userCall(n, f, c.depth); err != nil {
+		return 0, err
+	}
+	inner := *c
+	inner.depth++
+	inner.Vars = make(map[string]complex128, len(c.Vars)+len(f.Params))
+	for name, z := range c.Vars {
+		inner.Vars[name] = z
+	}
+	for i, a := range n.Args {
+		z, err := c.Eval(a)
+		if err != nil {
+			return 0, err
+		}
+		inner.Vars[f.Params[i]] = z
+	}
+	z, err := inner.Eval(f.Body)
+	if err != nil {
+		return 0, inFunction(n, err)
+	}
+	return z, nil
+}
+
+// clean drops a real or imaginary part that is only rounding noise next to
+// the other, so that exp(i pi) is -1
+func clean(z complex128) complex128 {
+	re, im := real(z), imag(z)
+	size := cmplx.Abs(z)
+	if math.Abs(re) < 1e-15*size {
+		re = 0
+	}
+	if math.Abs(im) < 1e-15*size {
+		im = 0
+	}
+	return complex(re, im)
+}
+
+// FormatComplex returns z in rectangular form, like 3 - 4i, as typed
+func FormatComplex(z complex128) string {
+	z = clean(z)
+	re, im := real(z), imag(z)
+	if im == 0 {
+		return Format(re)
+	}
+	var b strings.Builder
+	if re != 0 {
+		b.WriteString(Format(re))
+		if im < 0 {
+			b.WriteString(" - ")
+		} else {
+			b.WriteString(" + ")
+		}
+		im = math.Abs(im)
+	}
+	switch im {
+	case 1:
+	case -1:
+		b.WriteString("-")
+	default:
+		b.WriteString(Format(im))
+	}
+	b.WriteString(I)
+	return b.String()
+}
+
+// FormatPolar returns z in polar form r*e^(θi), with θ in radians so that
+// it can be typed back whatever the angle unit
+func FormatPolar(z complex128) string {
+	z = clean(z)
+	r, theta := cmplx.Polar(z)
+	if theta == 0 || r == 0 {
+		return Format(r)
+	}
+	power := "e^(" + FormatComplex(complex(0, theta)) + ")"
+	if Format(r) == "1" {
+		return power
+	}
+	return Format(r) + "*" + power
+}
@@ -0,0 +1,76 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComplex(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"sqrt(-1)", "i"},
+		{"sqrt(-4)", "2i"},
+		{"i^2", "-1"},
+		{"(3 + 4i)(3 - 4i)", "25"},
+		{"(1 + 2i)/(3 - 4i)", "-0.2 + 0.4i"},
+		{"abs(3 + 4i)", "5"},
+		{"conj(1 - i)", "1 + i"},
+		{"re(2 - 5i) + im(2 - 5i)", "-3"},
+		{"exp(i pi)", "-1"},
+		{"-i", "-i"},
+		{"ln(-1)", "3.14159265358979i"},
+		{"2 + 3", "5"},
+	}
+	c := NewComplex(NewEnv())
+	for _, test := range tests {
+		z, err := c.Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if got := FormatComplex(z); got != test.want {
+			t.Errorf("%s = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestFormatPolar(t *testing.T) {
+	tests := []struct {
+		in   complex128
+		want string
+	}{
+		{0, "0"},
+		{2, "2"},
+		{1i, "e^(1.5707963267949i)"},
+		{-2, "2*e^(3.14159265358979i)"},
+		{1 - 1i, "1.4142135623731*e^(-0.785398163397448i)"},
+	}
+	for _, test := range tests {
+		if got := FormatPolar(test.in); got != test.want {
+			t.Errorf("polar %v = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestComplexErrors(t *testing.T) {
+	tests := []struct {
+		in       string
+		pos, end int
+	}{
+		{"1/(i - i)", 2, 9},
+		{"1 + nope", 4, 8},
+	}
+	c := NewComplex(NewEnv())
+	for _, test := range tests {
+		_, err := c.Evaluate(test.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", test.in, err)
+			continue
+		}
+		if e.Pos != test.pos || e.End != test.end {
+			t.Errorf("%s: %q at %d-%d, want %d-%d", test.in, e.Msg, e.Pos, e.End, test.pos, test.end)
+		}
+	}
+}
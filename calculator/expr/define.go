@@ -0,0 +1,145 @@
+package expr
+
+import (
+	"strings"
+)
+
+// maxDepth limits how deep user functions may call each other
+const maxDepth = 64
+
+// Assignment is a line assigning a variable, x = 3, or defining a function,
+// f(x) = x^2 + 1. Params is nil for a variable
+type Assignment struct {
+	Name   string
+	Params []string
+	Body   Node
+	// Source is the text after the =
+	Source string
+}
+
+// IsFunction reports whether a defines a function
+func (a *Assignment) IsFunction() bool {
+	return a.Params != nil
+}
+
+// UserFunc is a function defined by the user
+type UserFunc struct {
+	Params []string
+	Body   Node
+	Source string
+}
+
+// String returns the definition as typed, like f(x) = x^2 + 1
+func (f *UserFunc) String(name string) string {
+	return name + "(" + strings.Join(f.Params, ", ") + ") = " + f.Source
+}
+
+// Reserved reports whether name belongs to a built-in function or constant
+// or to Ans
+func Reserved(name string) bool {
+	_, isFunc := Functions[name]
+	_, isConst := Constants[name]
+	return isFunc || isConst || name == "Ans" || name == "xor"
+}
+
+// ParseAssignment reads a line of the form name = expr or
+// name(params) = expr with bare numbers in base. It returns nil and no error
+// when s is not an assignment
+func ParseAssignment(s string, base int) (*Assignment, error) {
+	tokens, err := LexBase(s, base)
+	if err != nil {
+		return nil, err
+	}
+	eq := -1
+	for i, t := range tokens {
+		if t.Kind == Assign {
+			eq = i
+			break
+		}
+	}
+	if eq < 0 {
+		return nil, nil
+	}
+
+	head := tokens[:eq]
+	if len(head) == 0 || head[0].Kind != Name {
+		return nil, errorAt(tokens[eq].Pos, tokens[eq].End, "= needs a name on its left")
+	}
+	a := &Assignment{Name: head[0].Text}
+	if Reserved(a.Name) {
+		return nil, errorAt(head[0].Pos, head[0].End, "%s is built in and cannot be changed", a.Name)
+	}
+	if len(head) > 1 {
+		params, err := parseParams(head[1:], head[0])
+		if err != nil {
+			return nil, err
+		}
+		a.Params = params
+	}
+
+	p := &parser{tokens: tokens, i: eq + 1}
+	if p.peek().Kind == EOF {
+		return nil, errorAt(tokens[eq].Pos, tokens[eq].End, "missing value after =")
+	}
+	body, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != EOF {
+		return nil, errorAt(t.Pos, t.End, "unexpected %q", t.Text)
+	}
+	a.Body = body
+	a.Source = strings.TrimSpace(string([]rune(s)[tokens[eq].End:]))
+	return a, nil
+}
+
+// parseParams reads (x, y) in front of the = of a function definition
+func parseParams(tokens []Token, name Token) ([]string, error) {
+	last := tokens[len(tokens)-1]
+	if tokens[0].Kind != LParen || last.Kind != RParen {
+		return nil, errorAt(name.Pos, last.End, "expected a name or a function like f(x) before =")
+	}
+	params := make([]string, 0)
+	inner := tokens[1 : len(tokens)-1]
+	for i, t := range inner {
+		if i%2 == 1 {
+			if t.Kind != Comma {
+				return nil, errorAt(t.Pos, t.End, "expected , between parameters")
+			}
+			continue
+		}
+		if t.Kind != Name {
+			return nil, errorAt(t.Pos, t.End, "parameters must be names")
+		}
+		for _, p := range params {
+			if p == t.Text {
+				return nil, errorAt(t.Pos, t.End, "parameter %s is repeated", t.Text)
+			}
+		}
+		params = append(params, t.Text)
+	}
+	if len(inner) > 0 && len(inner)%2 == 0 {
+		return nil, errorAt(last.Pos, last.End, "missing parameter before )")
+	}
+	return params, nil
+}
+
+// userCall checks a call of f and returns the error of a wrong argument
+// count or of going too deep
+func userCall(n *Call, f *UserFunc, depth int) error {
+	if len(n.Args) != len(f.Params) {
+		return errorAt(n.Start, n.Stop, "%s takes %d argument(s)", n.Name, len(f.Params))
+	}
+	if depth >= maxDepth {
+		return errorAt(n.Start, n.Stop, "%s calls itself too deep", n.Name)
+	}
+	return nil
+}
+
+// inFunction moves an error from the body of a user function to its call
+func inFunction(n *Call, err error) error {
+	if e, ok := err.(*Error); ok {
+		return errorAt(n.Start, n.Stop, "in %s: %s", n.Name, e.Msg)
+	}
+	return err
+}
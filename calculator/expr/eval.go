@@ -0,0 +1,456 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Function is a built-in function taking between MinArgs and MaxArgs arguments.
+// Trigonometric functions set AngleArgs or AngleResult, their angles are in
+// radians and the evaluator converts them from and to the angle unit of the Env
+type Function struct {
+	MinArgs     int
+	MaxArgs     int
+	AngleArgs   bool
+	AngleResult bool
+	Call        func(args []float64) (float64, error)
+}
+
+// Angle is the unit of angles
+type Angle int
+
+const (
+	Radians Angle = iota
+	Degrees
+	Gradians
+)
+
+// Angles names the angle units as shown on the keypad
+var Angles = map[Angle]string{
+	Radians:  "RAD",
+	Degrees:  "DEG",
+	Gradians: "GRAD",
+}
+
+// toRadians converts an angle in unit a to radians
+func (a Angle) toRadians(v float64) float64 {
+	switch a {
+	case Degrees:
+		return v * math.Pi / 180
+	case Gradians:
+		return v * math.Pi / 200
+	}
+	return v
+}
+
+// fromRadians converts an angle in radians to unit a
+func (a Angle) fromRadians(v float64) float64 {
+	switch a {
+	case Degrees:
+		return v * 180 / math.Pi
+	case Gradians:
+		return v * 200 / math.Pi
+	}
+	return v
+}
+
+// Env holds the names an expression can refer to. Vars are looked up before
+// Consts, the calculator keeps Ans, the last result, and the variables of the
+// user there
+type Env struct {
+	Consts    map[string]float64
+	Vars      map[string]float64
+	Funcs     map[string]Function
+	UserFuncs map[string]*UserFunc
+	Angle     Angle
+
+	depth int
+}
+
+// Constants known to every expression
+var Constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// Functions known to every expression
+var Functions = map[string]Function{
+	"sin":       trig(func(x float64) (float64, error) { return snap(math.Sin(x)), nil }),
+	"cos":       trig(func(x float64) (float64, error) { return snap(math.Cos(x)), nil }),
+	"tan":       trig(tan),
+	"asin":      arc(domain(math.Asin, -1, 1)),
+	"acos":      arc(domain(math.Acos, -1, 1)),
+	"atan":      arc(func(x float64) (float64, error) { return math.Atan(x), nil }),
+	"sinh":      fn1(func(x float64) (float64, error) { return math.Sinh(x), nil }),
+	"cosh":      fn1(func(x float64) (float64, error) { return math.Cosh(x), nil }),
+	"tanh":      fn1(func(x float64) (float64, error) { return math.Tanh(x), nil }),
+	"asinh":     fn1(func(x float64) (float64, error) { return math.Asinh(x), nil }),
+	"acosh":     fn1(domain(math.Acosh, 1, math.Inf(1))),
+	"atanh":     fn1(atanh),
+	"sqrt":      fn1(sqrt),
+	"cbrt":      fn1(func(x float64) (float64, error) { return math.Cbrt(x), nil }),
+	"root":      {MinArgs: 2, MaxArgs: 2, Call: root},
+	"exp":       fn1(func(x float64) (float64, error) { return math.Exp(x), nil }),
+	"abs":       fn1(func(x float64) (float64, error) { return math.Abs(x), nil }),
+	"ln":        fn1(ln),
+	"log":       {MinArgs: 1, MaxArgs: 2, Call: log},
+	"log2":      fn1(func(x float64) (float64, error) { return log([]float64{x, 2}) }),
+	"factorial": fn1(factorial),
+	"arg":       {MinArgs: 1, MaxArgs: 1, AngleResult: true, Call: func(args []float64) (float64, error) { return math.Atan2(0, args[0]), nil }},
+	"conj":      fn1(func(x float64) (float64, error) { return x, nil }),
+	"re":        fn1(func(x float64) (float64, error) { return x, nil }),
+	"im":        fn1(func(x float64) (float64, error) { return 0, nil }),
+}
+
+// NewEnv returns an environment with the built-in constants and functions
+func NewEnv() *Env {
+	return &Env{Consts: Constants, Vars: make(map[string]float64), Funcs: Functions, UserFuncs: make(map[string]*UserFunc)}
+}
+
+// Evaluate parses and evaluates s with the built-in names
+func Evaluate(s string) (float64, error) {
+	return NewEnv().Evaluate(s)
+}
+
+// Evaluate parses and evaluates s
+func (env *Env) Evaluate(s string) (float64, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return env.Eval(n)
+}
+
+// Eval evaluates a parsed expression
+func (env *Env) Eval(n Node) (float64, error) {
+	switch n := n.(type) {
+	case *Num:
+		if math.IsInf(n.Value, 0) && n.Text != "" {
+			return 0, errorAt(n.Start, n.Stop, "%s is too large", n.Text)
+		}
+		return n.Value, nil
+	case *Paren:
+		return env.Eval(n.X)
+	case *Ident:
+		if v, ok := env.Vars[n.Name]; ok {
+			return v, nil
+		}
+		if v, ok := env.Consts[n.Name]; ok {
+			return v, nil
+		}
+		if _, ok := env.Funcs[n.Name]; ok {
+			return 0, errorAt(n.Start, n.Stop, "%s needs an argument, use %s(...)", n.Name, n.Name)
+		}
+		return 0, errorAt(n.Start, n.Stop, "unknown name %q", n.Name)
+	case *Call:
+		return env.call(n)
+	case *Unary:
+		x, err := env.Eval(n.X)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case "-":
+			return -x, nil
+		case "~":
+			i, err := whole(x, n.X)
+			return float64(^i), err
+		case "!":
+			v, err := factorial(x)
+			if err != nil {
+				return 0, errorAt(n.Start, n.Stop, "%v", err)
+			}
+			return v, nil
+		}
+		return x, nil
+	case *Binary:
+		return env.binary(n)
+	}
+	return 0, errorAt(n.Pos(), n.End(), "cannot evaluate")
+}
+
+func (env *Env) binary(n *Binary) (float64, error) {
+	x, err := env.Eval(n.X)
+	if err != nil {
+		return 0, err
+	}
+	y, err := env.Eval(n.Y)
+	if err != nil {
+		return 0, err
+	}
+	var v float64
+	switch n.Op {
+	case "&", "|", "xor", "<<", ">>":
+		return bitwise(n, x, y)
+	case "+":
+		v = x + y
+	case "-":
+		v = x - y
+	case "*":
+		v = x * y
+	case "/":
+		if y == 0 {
+			return 0, errorAt(n.Y.Pos(), n.Y.End(), "division by zero")
+		}
+		v = x / y
+	case "%":
+		if y == 0 {
+			return 0, errorAt(n.Y.Pos(), n.Y.End(), "modulo by zero")
+		}
+		v = math.Mod(x, y)
+	default:
+		v = math.Pow(x, y)
+		if math.IsNaN(v) {
+			return 0, errorAt(n.X.Pos(), n.Y.End(), "%s ^ %s is not a real number", Format(x), Format(y))
+		}
+	}
+	// Overflow would leave an Inf that later turns into 0
+	if math.IsInf(v, 0) {
+		return 0, errorAt(n.OpPos, n.OpPos+len([]rune(n.Op)), "result is too large")
+	}
+	return v, nil
+}
+
+// whole converts the value x of n for a bitwise operator
+func whole(x float64, n Node) (int64, error) {
+	if x != math.Trunc(x) || math.Abs(x) >= 1<<63 {
+		return 0, errorAt(n.Pos(), n.End(), "bitwise operators need whole numbers")
+	}
+	return int64(x), nil
+}
+
+func bitwise(n *Binary, x, y float64) (float64, error) {
+	a, err := whole(x, n.X)
+	if err != nil {
+		return 0, err
+	}
+	b, err := whole(y, n.Y)
+	if err != nil {
+		return 0, err
+	}
+	switch n.Op {
+	case "&":
+		return float64(a & b), nil
+	case "|":
+		return float64(a | b), nil
+	case "xor":
+		return float64(a ^ b), nil
+	}
+	if b < 0 || b > 63 {
+		return 0, errorAt(n.Y.Pos(), n.Y.End(), "shift must be between 0 and 63")
+	}
+	if n.Op == "<<" {
+		return float64(a << b), nil
+	}
+	return float64(a >> b), nil
+}
+
+func (env *Env) call(n *Call) (float64, error) {
+	if uf, ok := env.UserFuncs[n.Name]; ok {
+		return env.callUser(n, uf)
+	}
+	f, ok := env.Funcs[n.Name]
+	if !ok {
+		if len(n.Args) == 1 {
+			// pi(2) is pi times 2
+			c, err := env.Eval(&Ident{Name: n.Name, Start: n.Start, Stop: n.Start + len([]rune(n.Name))})
+			if err == nil {
+				x, err := env.Eval(n.Args[0])
+				return c * x, err
+			}
+		}
+		return 0, errorAt(n.Start, n.Start+len([]rune(n.Name)), "unknown function %q", n.Name)
+	}
+	if len(n.Args) < f.MinArgs || len(n.Args) > f.MaxArgs {
+		return 0, errorAt(n.Start, n.Stop, "%s takes %s", n.Name, arity(f))
+	}
+	args := make([]float64, len(n.Args))
+	for i, a := range n.Args {
+		v, err := env.Eval(a)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return env.Apply(n, f, args)
+}
+
+// callUser evaluates the body of a user function with its parameters set
+func (env *Env) callUser(n *Call, f *UserFunc) (float64, error) {
+	if err := userCall(n, f, env.depth); err != nil {
+		return 0, err
+	}
+	inner := *env
+	inner.depth++
+	inner.Vars = make(map[string]float64, len(env.Vars)+len(f.Params))
+	for name, v := range env.Vars {
+		inner.Vars[name] = v
+	}
+	for i, a := range n.Args {
+		v, err := env.Eval(a)
+		if err != nil {
+			return 0, err
+		}
+		inner.Vars[f.Params[i]] = v
+	}
+	v, err := inner.Eval(f.Body)
+	if err != nil {
+		return 0, inFunction(n, err)
+	}
+	return v, nil
+}
+
+// Apply calls f for n with evaluated arguments
+func (env *Env) Apply(n *Call, f Function, args []float64) (float64, error) {
+	if f.AngleArgs {
+		for i := range args {
+			args[i] = env.Angle.toRadians(args[i])
+		}
+	}
+	v, err := f.Call(args)
+	if err != nil {
+		return 0, errorAt(n.Start, n.Stop, "%s: %v", n.Name, err)
+	}
+	if math.IsInf(v, 0) {
+		return 0, errorAt(n.Start, n.Stop, "%s: result is too large", n.Name)
+	}
+	if f.AngleResult {
+		v = env.Angle.fromRadians(v)
+	}
+	return v, nil
+}
+
+func arity(f Function) string {
+	switch {
+	case f.MinArgs == f.MaxArgs && f.MinArgs == 1:
+		return "1 argument"
+	case f.MinArgs == f.MaxArgs:
+		return strconv.Itoa(f.MinArgs) + " arguments"
+	}
+	return strconv.Itoa(f.MinArgs) + " to " + strconv.Itoa(f.MaxArgs) + " arguments"
+}
+
+// Format prints v without float noise in the last digits
+func Format(v float64) string {
+	a := math.Abs(v)
+	if a != 0 && (a >= 1e21 || a < 1e-9) {
+		return strconv.FormatFloat(v, 'g', 15, 64)
+	}
+	s := strconv.FormatFloat(v, 'g', 15, 64)
+	f, _ := strconv.ParseFloat(s, 64)
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func fn1(f func(x float64) (float64, error)) Function {
+	return Function{MinArgs: 1, MaxArgs: 1, Call: func(args []float64) (float64, error) {
+		return f(args[0])
+	}}
+}
+
+// trig is a function of an angle
+func trig(f func(x float64) (float64, error)) Function {
+	t := fn1(f)
+	t.AngleArgs = true
+	return t
+}
+
+// arc is a function returning an angle
+func arc(f func(x float64) (float64, error)) Function {
+	a := fn1(f)
+	a.AngleResult = true
+	return a
+}
+
+// domain wraps f so that it fails outside of min to max
+func domain(f func(float64) float64, min, max float64) func(x float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		if x < min || x > max {
+			if math.IsInf(max, 1) {
+				return 0, fmt.Errorf("argument must be at least %s", Format(min))
+			}
+			return 0, fmt.Errorf("argument must be between %s and %s", Format(min), Format(max))
+		}
+		return f(x), nil
+	}
+}
+
+// snap drops the rounding error of sin(pi) and friends
+func snap(v float64) float64 {
+	if math.Abs(v) < 1e-15 {
+		return 0
+	}
+	return v
+}
+
+func tan(x float64) (float64, error) {
+	if math.Abs(math.Cos(x)) < 1e-15 {
+		return 0, errors.New("undefined for odd multiples of pi/2")
+	}
+	return snap(math.Tan(x)), nil
+}
+
+func sqrt(x float64) (float64, error) {
+	if x < 0 {
+		return 0, errors.New("negative argument")
+	}
+	return math.Sqrt(x), nil
+}
+
+func atanh(x float64) (float64, error) {
+	if x <= -1 || x >= 1 {
+		return 0, errors.New("argument must be between -1 and 1 exclusive")
+	}
+	return math.Atanh(x), nil
+}
+
+func root(args []float64) (float64, error) {
+	x, n := args[0], args[1]
+	if n == 0 {
+		return 0, errors.New("zeroth root")
+	}
+	if x < 0 {
+		// Odd roots of negative numbers are real
+		if n != math.Trunc(n) || math.Mod(n, 2) == 0 {
+			return 0, errors.New("even root of a negative number")
+		}
+		return -math.Pow(-x, 1/n), nil
+	}
+	return math.Pow(x, 1/n), nil
+}
+
+func ln(x float64) (float64, error) {
+	if x <= 0 {
+		return 0, errors.New("argument must be positive")
+	}
+	return math.Log(x), nil
+}
+
+func log(args []float64) (float64, error) {
+	v, err := ln(args[0])
+	if err != nil {
+		return 0, err
+	}
+	if len(args) == 1 {
+		return math.Log10(args[0]), nil
+	}
+	if args[1] <= 0 || args[1] == 1 {
+		return 0, errors.New("base must be positive and not 1")
+	}
+	return v / math.Log(args[1]), nil
+}
+
+func factorial(x float64) (float64, error) {
+	if x < 0 || x != math.Trunc(x) {
+		return 0, errors.New("factorial needs a whole number >= 0")
+	}
+	if x > 170 {
+		return 0, errors.New("factorial is too large")
+	}
+	v := 1.0
+	for i := 2.0; i <= x; i++ {
+		v *= i
+	}
+	return v, nil
+}
@@ -0,0 +1,529 @@
+package expr
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rounding is how Exact rounds a result to its decimal places
+type Rounding int
+
+const (
+	HalfUp Rounding = iota
+	HalfEven
+	Down
+	Up
+	Floor
+	Ceiling
+)
+
+// Roundings names the rounding modes as shown in the settings
+var Roundings = map[Rounding]string{
+	HalfUp:   "Half up",
+	HalfEven: "Half even",
+	Down:     "Towards zero",
+	Up:       "Away from zero",
+	Floor:    "Floor",
+	Ceiling:  "Ceiling",
+}
+
+// Digits of the constants beyond float64, so that exact results keep them
+var exactConstants = map[string]string{
+	"pi": "3.14159265358979323846264338327950288419716939937510582097494459",
+	"e":  "2.71828182845904523536028747135266249775724709369995957496696763",
+}
+
+// maxExponent keeps exact factorials and shifts from eating all memory
+const maxExponent = 10000
+
+// maxBits is the largest numerator or denominator a power or shift may
+// give, about 300000 digits
+const maxBits = 1 << 20
+
+// maxDigits is the largest decimal exponent of a number, it gives about
+// maxBits
+const maxDigits = maxBits * 3 / 10
+
+// Exact evaluates expressions with rational numbers, so 0.1+0.2 is 0.3 and
+// large integers keep every digit. Square roots and fractional powers are
+// computed with Prec bits, the other functions fall back to float64 through
+// Env
+type Exact struct {
+	Env      *Env
+	Vars     map[string]*big.Rat
+	Prec     uint
+	Places   int
+	Rounding Rounding
+
+	depth int
+}
+
+// NewExact returns an exact evaluator over env with 256 bits and 20 places
+func NewExact(env *Env) *Exact {
+	return &Exact{Env: env, Vars: make(map[string]*big.Rat), Prec: 256, Places: 20, Rounding: HalfUp}
+}
+
+// Evaluate parses and evaluates s
+func (x *Exact) Evaluate(s string) (*big.Rat, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return x.Eval(n)
+}
+
+// Eval evaluates a parsed expression
+func (x *Exact) Eval(n Node) (*big.Rat, error) {
+	switch n := n.(type) {
+	case *Num:
+		switch e := literalExponent(n.Text); {
+		case e > maxDigits:
+			return nil, errorAt(n.Start, n.Stop, "%s is too large", n.Text)
+		case e < -maxDigits:
+			return nil, errorAt(n.Start, n.Stop, "%s is too small", n.Text)
+		}
+		r, ok := new(big.Rat).SetString(n.Text)
+		if !ok {
+			return nil, errorAt(n.Start, n.Stop, "malformed number %q", n.Text)
+		}
+		return r, nil
+	case *Paren:
+		return x.Eval(n.X)
+	case *Ident:
+		if r, ok := x.Vars[n.Name]; ok {
+			return new(big.Rat).Set(r), nil
+		}
+		if _, ok := x.Env.Vars[n.Name]; !ok {
+			if digits, ok := exactConstants[n.Name]; ok {
+				r, _ := new(big.Rat).SetString(digits)
+				return r, nil
+			}
+		}
+		v, err := x.Env.Eval(n)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, errorAt(n.Start, n.Stop, "%s is not a finite number", n.Name)
+		}
+		return ratFromFloat(v), nil
+	case *Unary:
+		v, err := x.Eval(n.X)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case "-":
+			return v.Neg(v), nil
+		case "~":
+			i, err := exactWhole(v, n.X)
+			if err != nil {
+				return nil, err
+			}
+			return new(big.Rat).SetInt(i.Not(i)), nil
+		case "!":
+			r, err := exactFactorial(v)
+			if err != nil {
+				return nil, errorAt(n.Start, n.Stop, "%v", err)
+			}
+			return r, nil
+		}
+		return v, nil
+	case *Binary:
+		return x.binary(n)
+	case *Call:
+		return x.call(n)
+	}
+	return nil, errorAt(n.Pos(), n.End(), "cannot evaluate")
+}
+
+func (x *Exact) binary(n *Binary) (*big.Rat, error) {
+	a, err := x.Eval(n.X)
+	if err != nil {
+		return nil, err
+	}
+	b, err := x.Eval(n.Y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case "&", "|", "xor", "<<", ">>":
+		return exactBitwise(n, a, b)
+	case "+":
+		return a.Add(a, b), nil
+	case "-":
+		return a.Sub(a, b), nil
+	case "*":
+		return a.Mul(a, b), nil
+	case "/":
+		if b.Sign() == 0 {
+			return nil, errorAt(n.Y.Pos(), n.Y.End(), "division by zero")
+		}
+		return a.Quo(a, b), nil
+	case "%":
+		if b.Sign() == 0 {
+			return nil, errorAt(n.Y.Pos(), n.Y.End(), "modulo by zero")
+		}
+		// a - b*trunc(a/b), the sign follows a like math.Mod
+		q := new(big.Rat).Quo(a, b)
+		t := new(big.Int).Quo(q.Num(), q.Denom())
+		return a.Sub(a, b.Mul(b, new(big.Rat).SetInt(t))), nil
+	}
+	if b.IsInt() {
+		if b.Sign() < 0 && a.Sign() == 0 {
+			return nil, errorAt(n.X.Pos(), n.Y.End(), "division by zero")
+		}
+		if tooLarge(a, b) {
+			return nil, errorAt(n.X.Pos(), n.Y.End(), "result is too large")
+		}
+		switch {
+		case b.Num().IsInt64():
+			return ratPow(a, b.Num().Int64()), nil
+		case a.Sign() < 0:
+			// -1 is the only negative base left
+			if b.Num().Bit(0) == 1 {
+				return a, nil
+			}
+			return a.Neg(a), nil
+		default:
+			return a, nil
+		}
+	}
+	if q := b.Denom(); q.IsInt64() && q.Int64() <= maxExponent {
+		return x.root(n, a, b)
+	}
+	// Exponents like pi have no small denominator
+	fa, _ := a.Float64()
+	fb, _ := b.Float64()
+	v := math.Pow(fa, fb)
+	if math.IsInf(v, 0) {
+		return nil, errorAt(n.X.Pos(), n.Y.End(), "result is too large")
+	}
+	if math.IsNaN(v) {
+		return nil, errorAt(n.X.Pos(), n.Y.End(), "%s ^ %s is not a real number", Format(fa), Format(fb))
+	}
+	return ratFromFloat(v), nil
+}
+
+// root returns a^b for b = p/q as the q-th root of a raised to p, with Prec
+// bits like sqrt
+func (x *Exact) root(n *Binary, a, b *big.Rat) (*big.Rat, error) {
+	q := b.Denom().Int64()
+	switch {
+	case a.Sign() < 0 && q%2 == 0:
+		fa, _ := a.Float64()
+		fb, _ := b.Float64()
+		return nil, errorAt(n.X.Pos(), n.Y.End(), "%s ^ %s is not a real number", Format(fa), Format(fb))
+	case a.Sign() == 0 && b.Sign() < 0:
+		return nil, errorAt(n.X.Pos(), n.Y.End(), "division by zero")
+	case a.Sign() == 0:
+		return new(big.Rat), nil
+	case tooLarge(a, b):
+		return nil, errorAt(n.X.Pos(), n.Y.End(), "result is too large")
+	}
+	f := nthRoot(new(big.Float).SetPrec(x.Prec).SetRat(new(big.Rat).Abs(a)), q)
+	f = floatPow(f, b.Num())
+	// An odd root of a negative number is negative
+	if a.Sign() < 0 && b.Num().Bit(0) == 1 {
+		f.Neg(f)
+	}
+	r, _ := f.Rat(nil)
+	return r, nil
+}
+
+// nthRoot returns the q-th root of a > 0 at the precision of a, by Newton's
+// method from the float64 root
+func nthRoot(a *big.Float, q int64) *big.Float {
+	prec := a.Prec()
+	if q == 2 {
+		return new(big.Float).SetPrec(prec).Sqrt(a)
+	}
+	// a is m 2^e with e = kq + r, its root m^(1/q) 2^(r/q) 2^k
+	mant := new(big.Float)
+	e := int64(a.MantExp(mant))
+	k, r := e/q, e%q
+	if r < 0 {
+		k, r = k-1, r+q
+	}
+	m, _ := mant.Float64()
+	guess := math.Pow(m, 1/float64(q)) * math.Pow(2, float64(r)/float64(q))
+	root := new(big.Float).SetPrec(prec).SetMantExp(big.NewFloat(guess), int(k))
+
+	qf := new(big.Float).SetPrec(prec).SetInt64(q)
+	q1 := new(big.Float).SetPrec(prec).SetInt64(q - 1)
+	for i := 0; i < 100; i++ {
+		// root = ((q - 1) root + a / root^(q - 1)) / q
+		t := floatPow(root, big.NewInt(q-1))
+		t.Quo(a, t)
+		next := new(big.Float).SetPrec(prec).Mul(q1, root)
+		next.Add(next, t).Quo(next, qf)
+		if next.Cmp(root) == 0 {
+			break
+		}
+		root = next
+	}
+	return root
+}
+
+// floatPow returns f^p at the precision of f
+func floatPow(f *big.Float, p *big.Int) *big.Float {
+	prec := f.Prec()
+	r := new(big.Float).SetPrec(prec).SetInt64(1)
+	base := new(big.Float).SetPrec(prec).Set(f)
+	e := new(big.Int).Abs(p)
+	for i := 0; i < e.BitLen(); i++ {
+		if e.Bit(i) == 1 {
+			r.Mul(r, base)
+		}
+		base.Mul(base, base)
+	}
+	if p.Sign() < 0 {
+		r.Quo(new(big.Float).SetPrec(prec).SetInt64(1), r)
+	}
+	return r
+}
+
+// literalExponent returns the decimal exponent of a number like 1e400, 0
+// without one. Exponents beyond int64 are returned as ±Inf
+func literalExponent(text string) float64 {
+	if len(text) > 2 && text[0] == '0' && prefixBase(rune(text[1])) != 0 {
+		return 0
+	}
+	i := strings.IndexAny(text, "eE")
+	if i < 0 {
+		return 0
+	}
+	e, err := strconv.ParseInt(text[i+1:], 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if strings.HasPrefix(text[i+1:], "-") {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	return float64(e)
+}
+
+// tooLarge reports whether a^e, e whole, has more than maxBits in its
+// numerator or denominator. Powers of 0, 1 and -1 are never too large
+func tooLarge(a, e *big.Rat) bool {
+	bits := a.Num().BitLen()
+	if d := a.Denom().BitLen(); d > bits {
+		bits = d
+	}
+	f, _ := e.Float64()
+	return float64(bits-1)*math.Abs(f) > maxBits
+}
+
+// exactWhole converts the value r of n for a bitwise operator
+func exactWhole(r *big.Rat, n Node) (*big.Int, error) {
+	if !r.IsInt() {
+		return nil, errorAt(n.Pos(), n.End(), "bitwise operators need whole numbers")
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+func exactBitwise(n *Binary, x, y *big.Rat) (*big.Rat, error) {
+	a, err := exactWhole(x, n.X)
+	if err != nil {
+		return nil, err
+	}
+	b, err := exactWhole(y, n.Y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case "&":
+		return new(big.Rat).SetInt(a.And(a, b)), nil
+	case "|":
+		return new(big.Rat).SetInt(a.Or(a, b)), nil
+	case "xor":
+		return new(big.Rat).SetInt(a.Xor(a, b)), nil
+	}
+	if b.Sign() < 0 || b.Cmp(big.NewInt(maxExponent)) > 0 {
+		return nil, errorAt(n.Y.Pos(), n.Y.End(), "shift must be between 0 and %d", maxExponent)
+	}
+	if n.Op == "<<" {
+		if a.BitLen()+int(b.Int64()) > maxBits {
+			return nil, errorAt(n.X.Pos(), n.Y.End(), "result is too large")
+		}
+		return new(big.Rat).SetInt(a.Lsh(a, uint(b.Int64()))), nil
+	}
+	return new(big.Rat).SetInt(a.Rsh(a, uint(b.Int64()))), nil
+}
+
+func (x *Exact) call(n *Call) (*big.Rat, error) {
+	if uf, ok := x.Env.UserFuncs[n.Name]; ok {
+		return x.callUser(n, uf)
+	}
+	f, ok := x.Env.Funcs[n.Name]
+	if !ok || len(n.Args) < f.MinArgs || len(n.Args) > f.MaxArgs {
+		// Let Env report the error or multiply a name by its argument
+		v, err := x.Env.Eval(n)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, errorAt(n.Start, n.Stop, "result is not a finite number")
+		}
+		return ratFromFloat(v), nil
+	}
+	args := make([]*big.Rat, len(n.Args))
+	for i, a := range n.Args {
+		v, err := x.Eval(a)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch n.Name {
+	case "abs":
+		return args[0].Abs(args[0]), nil
+	case "factorial":
+		r, err := exactFactorial(args[0])
+		if err != nil {
+			return nil, errorAt(n.Start, n.Stop, "%s: %v", n.Name, err)
+		}
+		return r, nil
+	case "sqrt":
+		if args[0].Sign() < 0 {
+			return nil, errorAt(n.Start, n.Stop, "%s: negative argument", n.Name)
+		}
+		f := new(big.Float).SetPrec(x.Prec).SetRat(args[0])
+		r, _ := f.Sqrt(f).Rat(nil)
+		return r, nil
+	}
+
+	floats := make([]float64, len(args))
+	for i, a := range args {
+		floats[i], _ = a.Float64()
+	}
+	v, err := x.Env.Apply(n, f, floats)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return nil, errorAt(n.Start, n.Stop, "%s: result is not a finite number", n.Name)
+	}
+	return ratFromFloat(v), nil
+}
+
+// callUser evaluates the body of a user function with its parameters set
+func (x *Exact) callUser(n *Call, f *UserFunc) (*big.Rat, error) {
+	if err := userCall(n, f, x.depth); err != nil {
+		return nil, err
+	}
+	inner := *x
+	inner.depth++
+	inner.Vars = make(map[string]*big.Rat, len(x.Vars)+len(f.Params))
+	for name, v := range x.Vars {
+		inner.Vars[name] = v
+	}
+	for i, a := range n.Args {
+		v, err := x.Eval(a)
+		if err != nil {
+			return nil, err
+		}
+		inner.Vars[f.Params[i]] = v
+	}
+	v, err := inner.Eval(f.Body)
+	if err != nil {
+		return nil, inFunction(n, err)
+	}
+	return v, nil
+}
+
+// Format prints r with at most Places decimals, rounded with Rounding
+func (x *Exact) Format(r *big.Rat) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(x.Places)), nil)
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(scale))
+	n := round(scaled, x.Rounding)
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+	digits := n.String()
+	if x.Places == 0 {
+		return sign + digits
+	}
+	if len(digits) <= x.Places {
+		digits = strings.Repeat("0", x.Places-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-x.Places], strings.TrimRight(digits[len(digits)-x.Places:], "0")
+	if frac == "" {
+		if whole == "0" {
+			sign = ""
+		}
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// round rounds r to an integer
+func round(r *big.Rat, mode Rounding) *big.Int {
+	q, m := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() == 0 {
+		return q
+	}
+	negative := r.Sign() < 0
+	// Compare the dropped fraction with one half
+	half := new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(r.Denom())
+
+	away := false
+	switch mode {
+	case HalfUp:
+		away = half >= 0
+	case HalfEven:
+		away = half > 0 || (half == 0 && q.Bit(0) == 1)
+	case Up:
+		away = true
+	case Floor:
+		away = negative
+	case Ceiling:
+		away = !negative
+	}
+	if away {
+		if negative {
+			return q.Sub(q, big.NewInt(1))
+		}
+		return q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// ratFromFloat keeps the 15 significant digits a float64 result can be
+// trusted with, so that sin(pi/6) is 0.5 and not 0.49999999999999994
+func ratFromFloat(v float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', 15, 64))
+	return r
+}
+
+func ratPow(a *big.Rat, e int64) *big.Rat {
+	if e < 0 {
+		a = new(big.Rat).Inv(a)
+		e = -e
+	}
+	exp := big.NewInt(e)
+	num := new(big.Int).Exp(a.Num(), exp, nil)
+	den := new(big.Int).Exp(a.Denom(), exp, nil)
+	return new(big.Rat).SetFrac(num, den)
+}
+
+func exactFactorial(r *big.Rat) (*big.Rat, error) {
+	if !r.IsInt() || r.Sign() < 0 {
+		return nil, errors.New("factorial needs a whole number >= 0")
+	}
+	if !r.Num().IsInt64() || r.Num().Int64() > maxExponent {
+		return nil, errors.New("factorial is too large")
+	}
+	n := r.Num().Int64()
+	if n < 2 {
+		return big.NewRat(1, 1), nil
+	}
+	return new(big.Rat).SetInt(new(big.Int).MulRange(2, n)), nil
+}
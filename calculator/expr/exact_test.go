@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExact(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0.1+0.2", "0.3"},
+		{"1/3*3", "1"},
+		{"2^64", "18446744073709551616"},
+		{"2^-2", "0.25"},
+		{"-2^2", "-4"},
+		{"1e400/1e399", "10"},
+		{"(-1)^(10^30+1)", "-1"},
+		{"1^(10^30)", "1"},
+		{"0^(10^30)", "0"},
+		{"factorial(25)", "15511210043330985984000000"},
+		{"1 << 70", "1180591620717411303424"},
+		{"2/3", "0.66666666666666666667"},
+		{"2^0.5", "1.4142135623730950488"},
+		{"2^0.5 - sqrt(2)", "0"},
+		{"8^(1/3)", "2"},
+		{"(-8)^(1/3)", "-2"},
+		{"27^(4/3)", "81"},
+		{"4^-0.5", "0.5"},
+		{"2^0.001", "1.00069338746258063254"},
+		{"0^0.5", "0"},
+		{"1e-400*1e400", "1"},
+	}
+	x := NewExact(NewEnv())
+	for _, test := range tests {
+		r, err := x.Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if got := x.Format(r); got != test.want {
+			t.Errorf("%s = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestExactRounding(t *testing.T) {
+	tests := []struct {
+		in       string
+		rounding Rounding
+		want     string
+	}{
+		{"2.5", HalfUp, "3"},
+		{"2.5", HalfEven, "2"},
+		{"-2.5", HalfUp, "-3"},
+		{"-2.5", Floor, "-3"},
+		{"-2.5", Ceiling, "-2"},
+		{"2.1", Up, "3"},
+		{"2.9", Down, "2"},
+	}
+	x := NewExact(NewEnv())
+	x.Places = 0
+	for _, test := range tests {
+		r, err := x.Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		x.Rounding = test.rounding
+		if got := x.Format(r); got != test.want {
+			t.Errorf("%s rounded %s = %s, want %s", test.in, Roundings[test.rounding], got, test.want)
+		}
+	}
+}
+
+func TestExactTooLarge(t *testing.T) {
+	tests := []string{
+		"((2^10000)^10000)^10000",
+		"2^100000000",
+		"10^(10^30)",
+		"2^1048000 << 9000",
+		"10^(10^30/3)",
+		"1e400000000",
+	}
+	x := NewExact(NewEnv())
+	for _, in := range tests {
+		_, err := x.Evaluate(in)
+		if err == nil || !strings.Contains(err.Error(), "too large") {
+			t.Errorf("%s: got %v, want a too large error", in, err)
+		}
+	}
+}
+
+func TestExactErrors(t *testing.T) {
+	tests := []struct {
+		in, msg string
+	}{
+		{"(-8)^0.5", "-8 ^ 0.5 is not a real number"},
+		{"0^-0.5", "division by zero"},
+		{"1e-400000000", "1e-400000000 is too small"},
+		{"1e99999999999999999999", "1e99999999999999999999 is too large"},
+	}
+	x := NewExact(NewEnv())
+	for _, test := range tests {
+		_, err := x.Evaluate(test.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", test.in, err)
+			continue
+		}
+		if e.Msg != test.msg {
+			t.Errorf("%s: %q, want %q", test.in, e.Msg, test.msg)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package expr
+
+import (
+	"strings"
+)
+
+// Step is one reduction of an expression, Rule says what was worked out,
+// like "4 - 1 = 3, parentheses first", and Expression is the whole
+// expression after it
+type Step struct {
+	Rule       string
+	Expression string
+}
+
+// operations names the operators in the steps
+var operations = map[string]string{
+	"+":   "addition",
+	"-":   "subtraction",
+	"*":   "multiplication",
+	"/":   "division",
+	"%":   "modulo",
+	"^":   "power",
+	"&":   "bitwise and",
+	"|":   "bitwise or",
+	"xor": "bitwise xor",
+	"<<":  "shift",
+	">>":  "shift",
+}
+
+// Explain lists the steps evaluating n, parsed from source, takes in the
+// order the evaluators follow: operands from left to right, the innermost
+// parentheses and the tightest binding operators first. eval works out one
+// part of the expression, so that the steps show the results of any mode
+func Explain(source string, n Node, eval func(Node) (string, error)) ([]Step, error) {
+	e := &explainer{source: []rune(source), done: make(map[Node]string), parents: make(map[Node]Node)}
+	e.link(n, nil)
+	var steps []Step
+	for {
+		next := e.next(n)
+		if next == nil {
+			return steps, nil
+		}
+		before := e.render(next)
+		value, err := eval(next)
+		if err != nil {
+			return steps, err
+		}
+		if value == before || value == "1 "+before {
+			// Nothing to show, like i in complex mode or a unit, which is
+			// 1 of itself
+			e.done[next] = before
+			continue
+		}
+		e.done[next] = value
+		// Parentheses around a plain number are dropped with it
+		for p, ok := e.parents[next].(*Paren); ok; p, ok = e.parents[p].(*Paren) {
+			if plain(value) {
+				e.done[p] = value
+			} else {
+				e.done[p] = "(" + value + ")"
+			}
+		}
+		steps = append(steps, Step{Rule: before + " = " + value + e.reason(next), Expression: e.render(n)})
+	}
+}
+
+type explainer struct {
+	source  []rune
+	done    map[Node]string
+	parents map[Node]Node
+}
+
+func (e *explainer) link(n, parent Node) {
+	e.parents[n] = parent
+	switch n := n.(type) {
+	case *Num:
+		e.done[n] = string(e.source[n.Pos():n.End()])
+	case *Unary:
+		// A negative number is not a step
+		if _, ok := n.X.(*Num); ok && n.Op == "-" {
+			e.done[n] = string(e.source[n.Pos():n.End()])
+		}
+	}
+	for _, c := range children(n) {
+		e.link(c, n)
+	}
+}
+
+// next returns the first part of n, in evaluation order, whose operands
+// are all worked out, or nil once n is
+func (e *explainer) next(n Node) Node {
+	if _, ok := e.done[n]; ok {
+		return nil
+	}
+	for _, c := range children(n) {
+		if next := e.next(c); next != nil {
+			return next
+		}
+	}
+	if _, ok := n.(*Paren); ok {
+		// Only parentheses around a number, like (3), get here
+		return nil
+	}
+	return n
+}
+
+// reason tells why n came next
+func (e *explainer) reason(n Node) string {
+	if _, ok := e.parents[n].(*Paren); ok {
+		return ", parentheses first"
+	}
+	switch n := n.(type) {
+	case *Ident:
+		return ", value of " + n.Name
+	case *Call:
+		return ", function " + n.Name
+	case *Unary:
+		switch n.Op {
+		case "-":
+			return ", negation"
+		case "!":
+			return ", factorial"
+		case "~":
+			return ", bitwise not"
+		}
+	case *Binary:
+		if n.Implicit {
+			return ", implicit multiplication"
+		}
+		return ", " + operations[n.Op]
+	}
+	return ""
+}
+
+// render returns the source of n with the parts worked out replaced by
+// their values
+func (e *explainer) render(n Node) string {
+	if v, ok := e.done[n]; ok {
+		return v
+	}
+	var b strings.Builder
+	pos := n.Pos()
+	product := false
+	for _, c := range children(n) {
+		gap := string(e.source[pos:c.Pos()])
+		if bin, ok := n.(*Binary); ok && bin.Implicit && c == bin.Y && (e.changed(bin.X) || e.changed(bin.Y)) {
+			// 2sin(x) must not become 20.5
+			gap = " × "
+			product = true
+		}
+		b.WriteString(gap)
+		b.WriteString(e.render(c))
+		pos = c.End()
+	}
+	b.WriteString(string(e.source[pos:n.End()]))
+	text := strings.TrimSpace(b.String())
+	if product && e.tight(n) {
+		// 1/2pi is 1/(2 × pi), not 1/2 × pi
+		text = "(" + text + ")"
+	}
+	return text
+}
+
+// tight reports whether the parent of n binds at least as tightly as the *
+// written in place of its implicit multiplication
+func (e *explainer) tight(n Node) bool {
+	switch p := e.parents[n].(type) {
+	case *Binary:
+		return !p.Implicit && strings.Contains("*/%^", p.Op)
+	case *Unary, *Call:
+		return true
+	}
+	return false
+}
+
+// changed reports whether n was replaced by a value that is not its source
+func (e *explainer) changed(n Node) bool {
+	v, ok := e.done[n]
+	return ok && v != string(e.source[n.Pos():n.End()])
+}
+
+// plain reports whether v is a number that needs no parentheses, like 12,
+// 0.5 or 3C, and unlike -2 or 3 + 4i
+func plain(v string) bool {
+	return v != "" && !strings.ContainsAny(v, " +-*/%^()")
+}
+
+// children returns the operands of n from left to right
+func children(n Node) []Node {
+	switch n := n.(type) {
+	case *Paren:
+		return []Node{n.X}
+	case *Unary:
+		return []Node{n.X}
+	case *Binary:
+		return []Node{n.X, n.Y}
+	case *Call:
+		return n.Args
+	}
+	return nil
+}
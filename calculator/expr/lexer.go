@@ -0,0 +1,229 @@
+// Package expr is the expression engine of the calculator.
+//
+// It turns a line like "2sin(pi/6) + 3!" into tokens, parses the tokens into
+// a tree following the usual operator precedence and evaluates the tree.
+// Every error carries the rune position of the part of the input at fault.
+//
+// Grammar, from the loosest to the tightest binding:
+//
+//	expr     = bitxor { "|" bitxor }
+//	bitxor   = bitand { "xor" bitand }
+//	bitand   = shift { "&" shift }
+//	shift    = sum { ("<<" | ">>") sum }
+//	sum      = term { ("+" | "-") term }
+//	term     = implicit { ("*" | "/" | "%") implicit }
+//	implicit = unary { power }                     // juxtaposition, 1/2pi is 1/(2pi)
+//	unary    = ("+" | "-" | "~") unary | power
+//	power    = postfix [ "^" unary ]               // right associative
+//	postfix  = primary { "!" }
+//	primary  = number | name | name "(" [ expr { "," expr } ] ")" | "(" expr ")"
+//
+// A line can also assign a variable, x = 3, or define a function,
+// f(x) = x^2 + 1, see ParseAssignment.
+//
+// Numbers are decimal unless prefixed with 0x, 0o or 0b. Programmer mode
+// parses with LexBase so that bare numbers are read in another base.
+// Complex mode reads the name i as the imaginary unit, so 3+4i is 3 + 4*i.
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kind is the type of a token
+type Kind int
+
+const (
+	EOF Kind = iota
+	Number
+	Name
+	Operator // + - * / % ^ ! & | ~ << >> xor
+	LParen
+	RParen
+	Comma
+	Assign
+)
+
+// Token is a piece of the input, Pos and End are rune offsets
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  int
+	End  int
+}
+
+// Error is a syntax or evaluation error covering the runes Pos to End of the input
+type Error struct {
+	Pos int
+	End int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+func errorAt(pos, end int, format string, args ...interface{}) *Error {
+	if end <= pos {
+		end = pos + 1
+	}
+	return &Error{Pos: pos, End: end, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Aliases lets calculator keys and pasted text use the usual symbols
+var Aliases = map[rune]string{
+	'×': "*",
+	'÷': "/",
+	'−': "-",
+	'π': "pi",
+	'√': "sqrt",
+}
+
+// Lex splits s into tokens, the last one is always EOF
+func Lex(s string) ([]Token, error) {
+	return LexBase(s, 10)
+}
+
+// LexBase splits s into tokens reading bare numbers in base 2, 8, 10 or 16.
+// Other bases get their numbers as Number tokens with a 0b, 0o or 0x prefix
+func LexBase(s string, base int) ([]Token, error) {
+	runes := []rune(s)
+	tokens := make([]Token, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '0' && i+1 < len(runes) && prefixBase(runes[i+1]) != 0:
+			start := i
+			i = scanWord(runes, i+2)
+			text := string(runes[start:i])
+			if !isDigits(runes[start+2:i], prefixBase(runes[start+1])) {
+				return nil, errorAt(start, i, "malformed number %q", text)
+			}
+			tokens = append(tokens, Token{Number, text, start, i})
+		case base != 10 && (unicode.IsDigit(r) || unicode.IsLetter(r)):
+			start := i
+			i = scanWord(runes, i)
+			word := runes[start:i]
+			switch {
+			case isDigits(word, base):
+				tokens = append(tokens, Token{Number, basePrefix[base] + string(word), start, i})
+			case unicode.IsDigit(r):
+				return nil, errorAt(start, i, "%q is not a base %d number", string(word), base)
+			default:
+				tokens = append(tokens, name(string(word), start, i))
+			}
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			i = scanNumber(runes, i)
+			text := string(runes[start:i])
+			if strings.Count(text, ".") > 1 || text == "." {
+				return nil, errorAt(start, i, "malformed number %q", text)
+			}
+			tokens = append(tokens, Token{Number, text, start, i})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			i = scanWord(runes, i)
+			tokens = append(tokens, name(string(runes[start:i]), start, i))
+		case (r == '<' || r == '>') && i+1 < len(runes) && runes[i+1] == r:
+			tokens = append(tokens, Token{Operator, string(runes[i : i+2]), i, i + 2})
+			i += 2
+		case strings.ContainsRune("+-*/%^!&|~", r):
+			tokens = append(tokens, Token{Operator, string(r), i, i + 1})
+			i++
+		case r == '(':
+			tokens = append(tokens, Token{LParen, "(", i, i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, Token{RParen, ")", i, i + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, Token{Comma, ",", i, i + 1})
+			i++
+		case r == '=':
+			tokens = append(tokens, Token{Assign, "=", i, i + 1})
+			i++
+		default:
+			alias, ok := Aliases[r]
+			if !ok {
+				return nil, errorAt(i, i+1, "unexpected character %q", r)
+			}
+			kind := Operator
+			if unicode.IsLetter(rune(alias[0])) {
+				kind = Name
+			}
+			tokens = append(tokens, Token{kind, alias, i, i + 1})
+			i++
+		}
+	}
+	return append(tokens, Token{EOF, "", len(runes), len(runes)}), nil
+}
+
+// name returns the token of a word, xor is an operator
+func name(word string, start, end int) Token {
+	if word == "xor" {
+		return Token{Operator, word, start, end}
+	}
+	return Token{Name, word, start, end}
+}
+
+// basePrefix is put in front of the numbers read by LexBase
+var basePrefix = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+
+// prefixBase returns the base of a 0b, 0o or 0x prefix or 0
+func prefixBase(r rune) int {
+	switch unicode.ToLower(r) {
+	case 'b':
+		return 2
+	case 'o':
+		return 8
+	case 'x':
+		return 16
+	}
+	return 0
+}
+
+// scanWord reads letters, digits and underscores
+func scanWord(runes []rune, i int) int {
+	for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+		i++
+	}
+	return i
+}
+
+// isDigits reports whether word is a number in base
+func isDigits(word []rune, base int) bool {
+	if len(word) == 0 {
+		return false
+	}
+	for _, r := range word {
+		d := strings.IndexRune("0123456789abcdef", unicode.ToLower(r))
+		if d < 0 || d >= base {
+			return false
+		}
+	}
+	return true
+}
+
+// scanNumber reads digits, one decimal point and an exponent like e-3
+func scanNumber(runes []rune, i int) int {
+	for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+		i++
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && unicode.IsDigit(runes[j]) {
+			for j < len(runes) && unicode.IsDigit(runes[j]) {
+				j++
+			}
+			return j
+		}
+	}
+	return i
+}
@@ -0,0 +1,286 @@
+package expr
+
+import (
+	"errors"
+	"strconv"
+)
+
+type parser struct {
+	tokens []Token
+	i      int
+}
+
+// Parse reads s into an expression tree
+func Parse(s string) (Node, error) {
+	return ParseBase(s, 10)
+}
+
+// ParseBase reads s into an expression tree with bare numbers in base
+func ParseBase(s string, base int) (Node, error) {
+	tokens, err := LexBase(s, base)
+	if err != nil {
+		return nil, err
+	}
+	return parseTokens(tokens)
+}
+
+// ParseConversion reads a line like "5 km + 300 m in mi" into the value
+// and the target after the last "in" or "to". target is nil when there is
+// no conversion
+func ParseConversion(s string) (value Node, target Node, err error) {
+	tokens, err := Lex(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	split := -1
+	for i := len(tokens) - 3; i > 0; i-- {
+		if t := tokens[i]; t.Kind == Name && (t.Text == "in" || t.Text == "to") {
+			split = i
+			break
+		}
+	}
+	if split < 0 {
+		value, err = parseTokens(tokens)
+		return value, nil, err
+	}
+	left := append(append([]Token{}, tokens[:split]...), Token{EOF, "", tokens[split].Pos, tokens[split].Pos})
+	if value, err = parseTokens(left); err != nil {
+		return nil, nil, err
+	}
+	if target, err = parseTokens(tokens[split+1:]); err != nil {
+		return nil, nil, err
+	}
+	return value, target, nil
+}
+
+// parseTokens parses a whole expression ending with EOF
+func parseTokens(tokens []Token) (Node, error) {
+	p := &parser{tokens: tokens}
+	if t := p.peek(); t.Kind == EOF {
+		return nil, errorAt(t.Pos, t.End, "empty expression")
+	}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != EOF {
+		if t.Kind == RParen {
+			return nil, errorAt(t.Pos, t.End, "unmatched )")
+		}
+		return nil, errorAt(t.Pos, t.End, "unexpected %q", t.Text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() Token {
+	t := p.tokens[p.i]
+	if t.Kind != EOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.Kind != Operator {
+		return false
+	}
+	for _, op := range ops {
+		if t.Text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// levels are the left associative binary operators looser than term, from
+// the loosest
+var levels = [][]string{
+	{"|"},
+	{"xor"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+}
+
+func (p *parser) expr() (Node, error) {
+	return p.level(0)
+}
+
+func (p *parser) level(i int) (Node, error) {
+	if i == len(levels) {
+		return p.term()
+	}
+	x, err := p.level(i + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(levels[i]...) {
+		op := p.next()
+		y, err := p.level(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: op.Text, X: x, Y: y, OpPos: op.Pos}
+	}
+	return x, nil
+}
+
+func (p *parser) term() (Node, error) {
+	x, err := p.implicit()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/", "%") {
+		op := p.next()
+		y, err := p.implicit()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: op.Text, X: x, Y: y, OpPos: op.Pos}
+	}
+	return x, nil
+}
+
+// implicit reads multiplications without an operator, like 2pi or 5 km
+func (p *parser) implicit() (Node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch t := p.peek(); t.Kind {
+		case Name, LParen:
+			y, err := p.power()
+			if err != nil {
+				return nil, err
+			}
+			x = &Binary{Op: "*", X: x, Y: y, OpPos: t.Pos, Implicit: true}
+		case Number:
+			return nil, errorAt(t.Pos, t.End, "missing operator before %s", t.Text)
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) unary() (Node, error) {
+	if p.isOp("+", "-", "~") {
+		op := p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: op.Text, X: x, Start: op.Pos, Stop: x.End()}, nil
+	}
+	return p.power()
+}
+
+func (p *parser) power() (Node, error) {
+	x, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") {
+		op := p.next()
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Binary{Op: "^", X: x, Y: y, OpPos: op.Pos}, nil
+	}
+	return x, nil
+}
+
+func (p *parser) postfix() (Node, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("!") {
+		op := p.next()
+		x = &Unary{Op: "!", X: x, Start: x.Pos(), Stop: op.End}
+	}
+	return x, nil
+}
+
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+	switch t.Kind {
+	case Number:
+		v, err := parseNumber(t.Text)
+		if err != nil {
+			return nil, errorAt(t.Pos, t.End, "malformed number %q", t.Text)
+		}
+		return &Num{Value: v, Text: t.Text, Start: t.Pos, Stop: t.End}, nil
+	case Name:
+		if p.peek().Kind != LParen {
+			return &Ident{Name: t.Text, Start: t.Pos, Stop: t.End}, nil
+		}
+		p.next()
+		args, end, err := p.args(t)
+		if err != nil {
+			return nil, err
+		}
+		return &Call{Name: t.Text, Args: args, Start: t.Pos, Stop: end}, nil
+	case LParen:
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing.Kind != RParen {
+			return nil, errorAt(t.Pos, t.End, "missing )")
+		}
+		return &Paren{X: x, Start: t.Pos, Stop: closing.End}, nil
+	case EOF:
+		return nil, errorAt(t.Pos, t.End, "unexpected end of expression")
+	case RParen:
+		return nil, errorAt(t.Pos, t.End, "missing value before )")
+	}
+	return nil, errorAt(t.Pos, t.End, "unexpected %q", t.Text)
+}
+
+// args reads the arguments of a call after its opening parenthesis
+func (p *parser) args(name Token) ([]Node, int, error) {
+	args := make([]Node, 0)
+	if t := p.peek(); t.Kind == RParen {
+		p.next()
+		return args, t.End, nil
+	}
+	for {
+		x, err := p.expr()
+		if err != nil {
+			return nil, 0, err
+		}
+		args = append(args, x)
+		switch t := p.next(); t.Kind {
+		case Comma:
+		case RParen:
+			return args, t.End, nil
+		case EOF:
+			return nil, 0, errorAt(name.Pos, name.End+1, "missing ) after arguments of %s", name.Text)
+		default:
+			return nil, 0, errorAt(t.Pos, t.End, "expected , or ) but found %q", t.Text)
+		}
+	}
+}
+
+// parseNumber reads a decimal number or a whole number with a base prefix.
+// Decimals beyond float64, like 1e400, are read as infinity for the float
+// evaluator to reject, exact mode reads their text
+func parseNumber(text string) (float64, error) {
+	if len(text) > 2 && text[0] == '0' && prefixBase(rune(text[1])) != 0 {
+		n, err := strconv.ParseUint(text, 0, 64)
+		return float64(n), err
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return v, nil
+	}
+	return v, err
+}
@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		// Precedence
+		{"2+3*4", 14},
+		{"(2+3)*4", 20},
+		{"10-4-3", 3},
+		{"2^3^2", 512},
+		{"2*3^2", 18},
+		{"7%4+1", 4},
+		{"3!+1", 7},
+		{"1 + 2 << 1", 6},
+		// Unary minus binds looser than ^
+		{"-2^2", -4},
+		{"(-2)^2", 4},
+		{"2^-1", 0.5},
+		{"--3", 3},
+		{"-3!", -6},
+		// Implicit multiplication binds tighter than / but looser than ^
+		{"2pi", 2 * 3.141592653589793},
+		{"3(4)", 12},
+		{"(1+1)(2+1)", 6},
+		{"2 sqrt(9)", 6},
+		{"1/2pi", 1 / (2 * 3.141592653589793)},
+		{"2pi^2", 2 * 3.141592653589793 * 3.141592653589793},
+		{"-2(3)", -6},
+		// Numbers
+		{"1.5e3", 1500},
+		{".5", 0.5},
+	}
+	for _, test := range tests {
+		got, err := Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s = %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		in       string
+		pos, end int
+		msg      string
+	}{
+		{"1 + * 2", 4, 5, `unexpected "*"`},
+		{"", 0, 1, "empty expression"},
+		{"1 +", 3, 4, "unexpected end of expression"},
+		{"(1 + 2", 0, 1, "missing )"},
+		{"1 + 2)", 5, 6, "unmatched )"},
+		{"2 3", 2, 3, "missing operator before 3"},
+		{"1 + ()", 5, 6, "missing value before )"},
+		{"1 + 2 $", 6, 7, "unexpected character '$'"},
+		{"π + 1 +", 7, 8, "unexpected end of expression"},
+		{"foo + 1", 0, 3, `unknown name "foo"`},
+		{"1 + sqrt(-1)", 4, 12, ""},
+		{"1 / (2 - 2)", 4, 11, "division by zero"},
+	}
+	for _, test := range tests {
+		_, err := Evaluate(test.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", test.in, err)
+			continue
+		}
+		if e.Pos != test.pos || e.End != test.end {
+			t.Errorf("%s: error at %d-%d, want %d-%d", test.in, e.Pos, e.End, test.pos, test.end)
+		}
+		if test.msg != "" && e.Msg != test.msg {
+			t.Errorf("%s: %q, want %q", test.in, e.Msg, test.msg)
+		}
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	tests := []struct {
+		in       string
+		pos, end int
+		msg      string
+	}{
+		{"1e400", 0, 5, "1e400 is too large"},
+		{"1e300*1e300", 5, 6, "result is too large"},
+		{"1 + 10^400", 6, 7, "result is too large"},
+		{"-1e308 - 1e308", 7, 8, "result is too large"},
+		{"exp(1000)", 0, 9, "exp: result is too large"},
+	}
+	for _, test := range tests {
+		_, err := Evaluate(test.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", test.in, err)
+			continue
+		}
+		if e.Pos != test.pos || e.End != test.end || e.Msg != test.msg {
+			t.Errorf("%s: %q at %d-%d, want %q at %d-%d", test.in, e.Msg, e.Pos, e.End, test.msg, test.pos, test.end)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Programmer evaluates expressions with integers of a fixed word size that
+// wrap around on overflow like the machine types of the same size. Bare
+// numbers are read in Base
+type Programmer struct {
+	Base      int
+	Bits      int
+	Signed    bool
+	Vars      map[string]uint64
+	UserFuncs map[string]*UserFunc
+
+	depth int
+}
+
+// Bases of programmer mode
+var Bases = []int{16, 10, 8, 2}
+
+// WordSizes of programmer mode in bits
+var WordSizes = []int{8, 16, 32, 64}
+
+// NewProgrammer returns a programmer evaluator for signed 64 bit decimal numbers
+func NewProgrammer() *Programmer {
+	return &Programmer{Base: 10, Bits: 64, Signed: true, Vars: make(map[string]uint64), UserFuncs: make(map[string]*UserFunc)}
+}
+
+// Evaluate parses s in Base and evaluates it, the result is masked to the
+// word size
+func (p *Programmer) Evaluate(s string) (uint64, error) {
+	n, err := ParseBase(s, p.Base)
+	if err != nil {
+		return 0, err
+	}
+	return p.Eval(n)
+}
+
+// Mask cuts v down to the word size
+func (p *Programmer) Mask(v uint64) uint64 {
+	if p.Bits >= 64 {
+		return v
+	}
+	return v & (1<<uint(p.Bits) - 1)
+}
+
+// Int returns v as a signed number of the word size
+func (p *Programmer) Int(v uint64) int64 {
+	v = p.Mask(v)
+	if p.Bits < 64 && v&(1<<uint(p.Bits-1)) != 0 {
+		return int64(v) - 1<<uint(p.Bits)
+	}
+	return int64(v)
+}
+
+// negative reports whether v is below zero in signed mode
+func (p *Programmer) negative(v uint64) bool {
+	return p.Signed && p.Int(v) < 0
+}
+
+// Eval evaluates a parsed expression
+func (p *Programmer) Eval(n Node) (uint64, error) {
+	switch n := n.(type) {
+	case *Num:
+		return p.number(n)
+	case *Paren:
+		return p.Eval(n.X)
+	case *Ident:
+		if v, ok := p.Vars[n.Name]; ok {
+			return p.Mask(v), nil
+		}
+		return 0, errorAt(n.Start, n.Stop, "unknown name %q", n.Name)
+	case *Call:
+		if f, ok := p.UserFuncs[n.Name]; ok {
+			return p.callUser(n, f)
+		}
+		return 0, errorAt(n.Start, n.Stop, "only your own functions are available in programmer mode")
+	case *Unary:
+		x, err := p.Eval(n.X)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case "-":
+			return p.Mask(-x), nil
+		case "~":
+			return p.Mask(^x), nil
+		case "!":
+			if p.negative(x) {
+				return 0, errorAt(n.Start, n.Stop, "factorial needs a whole number >= 0")
+			}
+			v := uint64(1)
+			for i := uint64(2); i <= x && v != 0; i++ {
+				v = p.Mask(v * i)
+			}
+			return v, nil
+		}
+		return x, nil
+	case *Binary:
+		return p.binary(n)
+	}
+	return 0, errorAt(n.Pos(), n.End(), "cannot evaluate")
+}
+
+// callUser evaluates the body of a user function with its parameters set
+func (p *Programmer) callUser(n *Call, f *UserFunc) (uint64, error) {
+	if err := userCall(n, f, p.depth); err != nil {
+		return 0, err
+	}
+	inner := *p
+	inner.depth++
+	inner.Vars = make(map[string]uint64, len(p.Vars)+len(f.Params))
+	for name, v := range p.Vars {
+		inner.Vars[name] = v
+	}
+	for i, a := range n.Args {
+		v, err := p.Eval(a)
+		if err != nil {
+			return 0, err
+		}
+		inner.Vars[f.Params[i]] = v
+	}
+	v, err := inner.Eval(f.Body)
+	if err != nil {
+		return 0, inFunction(n, err)
+	}
+	return v, nil
+}
+
+func (p *Programmer) number(n *Num) (uint64, error) {
+	if strings.ContainsAny(n.Text, ".eE") && !strings.HasPrefix(strings.ToLower(n.Text), "0x") {
+		return 0, errorAt(n.Start, n.Stop, "programmer mode works with whole numbers")
+	}
+	v, err := strconv.ParseUint(n.Text, 0, 64)
+	if err != nil {
+		return 0, errorAt(n.Start, n.Stop, "%s does not fit in 64 bits", n.Text)
+	}
+	if p.Mask(v) != v {
+		return 0, errorAt(n.Start, n.Stop, "%s does not fit in %d bits", n.Text, p.Bits)
+	}
+	return v, nil
+}
+
+func (p *Programmer) binary(n *Binary) (uint64, error) {
+	x, err := p.Eval(n.X)
+	if err != nil {
+		return 0, err
+	}
+	y, err := p.Eval(n.Y)
+	if err != nil {
+		return 0, err
+	}
+	switch n.Op {
+	case "+":
+		return p.Mask(x + y), nil
+	case "-":
+		return p.Mask(x - y), nil
+	case "*":
+		return p.Mask(x * y), nil
+	case "&":
+		return x & y, nil
+	case "|":
+		return x | y, nil
+	case "xor":
+		return x ^ y, nil
+	case "/", "%":
+		if y == 0 {
+			return 0, errorAt(n.Y.Pos(), n.Y.End(), "division by zero")
+		}
+		if p.Signed {
+			a, b := p.Int(x), p.Int(y)
+			if b == -1 {
+				// Avoid the overflow of the smallest number divided by -1
+				if n.Op == "%" {
+					return 0, nil
+				}
+				return p.Mask(uint64(-a)), nil
+			}
+			if n.Op == "/" {
+				return p.Mask(uint64(a / b)), nil
+			}
+			return p.Mask(uint64(a % b)), nil
+		}
+		if n.Op == "/" {
+			return x / y, nil
+		}
+		return x % y, nil
+	case "<<", ">>":
+		if p.negative(y) || y >= uint64(p.Bits) {
+			return 0, errorAt(n.Y.Pos(), n.Y.End(), "shift must be between 0 and %d", p.Bits-1)
+		}
+		if n.Op == "<<" {
+			return p.Mask(x << y), nil
+		}
+		if p.Signed {
+			// Arithmetic shift keeps the sign
+			return p.Mask(uint64(p.Int(x) >> y)), nil
+		}
+		return x >> y, nil
+	}
+	// Power
+	if p.negative(y) {
+		return 0, errorAt(n.Y.Pos(), n.Y.End(), "negative exponent in programmer mode")
+	}
+	v := uint64(1)
+	for base, e := x, y; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			v = p.Mask(v * base)
+		}
+		base = p.Mask(base * base)
+	}
+	return v, nil
+}
+
+// Format prints v in base, negative numbers in signed decimal keep their
+// sign and the other bases show their two's complement
+func (p *Programmer) Format(v uint64, base int) string {
+	v = p.Mask(v)
+	if base == 10 && p.Signed {
+		return strconv.FormatInt(p.Int(v), 10)
+	}
+	return strings.ToUpper(strconv.FormatUint(v, base))
+}
+
+// Input prints v as it is typed in Base, with a minus sign for negative
+// signed numbers
+func (p *Programmer) Input(v uint64) string {
+	if p.negative(v) {
+		return "-" + strings.ToUpper(strconv.FormatUint(-uint64(p.Int(v)), p.Base))
+	}
+	return strings.ToUpper(strconv.FormatUint(p.Mask(v), p.Base))
+}
+
+// Bit reports whether bit i of v is set
+func Bit(v uint64, i int) bool {
+	return v&(1<<uint(i)) != 0
+}
+
+// ToggleBit flips bit i of v
+func ToggleBit(v uint64, i int) uint64 {
+	return v ^ 1<<uint(i)
+}
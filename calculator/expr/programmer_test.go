@@ -0,0 +1,110 @@
+package expr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProgrammer(t *testing.T) {
+	tests := []struct {
+		in     string
+		base   int
+		bits   int
+		signed bool
+		want   string // In base 10
+	}{
+		{"7/2", 10, 64, true, "3"},
+		{"-7/2", 10, 64, true, "-3"},
+		{"-7%2", 10, 64, true, "-1"},
+		{"127+1", 10, 8, true, "-128"},
+		{"255+1", 10, 8, false, "0"},
+		{"0-1", 10, 8, false, "255"},
+		{"-128/-1", 10, 8, true, "-128"},
+		{"-16 >> 2", 10, 32, true, "-4"},
+		{"1 << 7", 10, 8, false, "128"},
+		{"6 & 3 | 8 xor 1", 10, 64, true, "11"},
+		{"~0", 10, 16, false, "65535"},
+		{"2^10", 10, 16, true, "1024"},
+		{"2^16", 10, 16, false, "0"},
+		{"FF + 1", 16, 64, true, "256"},
+		{"ff", 16, 8, false, "255"},
+		{"1010", 2, 8, false, "10"},
+		{"17", 8, 8, false, "15"},
+		{"0x10 + 0b11", 10, 64, true, "19"},
+		{"0X1E", 10, 8, false, "30"},
+		{"0x1e + 0X1E", 10, 64, true, "60"},
+	}
+	for _, test := range tests {
+		p := NewProgrammer()
+		p.Base, p.Bits, p.Signed = test.base, test.bits, test.signed
+		v, err := p.Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if got := p.Format(v, 10); got != test.want {
+			t.Errorf("%s in base %d, %d bits = %s, want %s", test.in, test.base, test.bits, got, test.want)
+		}
+	}
+}
+
+func TestProgrammerFormat(t *testing.T) {
+	p := NewProgrammer()
+	p.Bits = 8
+	v, err := p.Evaluate("-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		base int
+		want string
+	}{
+		{16, "FF"},
+		{10, "-1"},
+		{8, "377"},
+		{2, "11111111"},
+	}
+	for _, test := range tests {
+		if got := p.Format(v, test.base); got != test.want {
+			t.Errorf("-1 in base %d = %s, want %s", test.base, got, test.want)
+		}
+	}
+	if got := p.Input(v); got != "-1" {
+		t.Errorf("input of -1 = %s, want -1", got)
+	}
+}
+
+func TestProgrammerErrors(t *testing.T) {
+	tests := []struct {
+		in, msg string
+	}{
+		{"1.5", "programmer mode works with whole numbers"},
+		{"1/0", "division by zero"},
+		{"256", "256 does not fit in 8 bits"},
+		{"1 << 8", "shift must be between 0 and 7"},
+		{"2^-1", "negative exponent in programmer mode"},
+	}
+	p := NewProgrammer()
+	p.Bits = 8
+	for _, test := range tests {
+		_, err := p.Evaluate(test.in)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%s: got %v, want an *Error", test.in, err)
+			continue
+		}
+		if e.Msg != test.msg {
+			t.Errorf("%s: %q, want %q", test.in, e.Msg, test.msg)
+		}
+	}
+}
+
+func TestToggleBit(t *testing.T) {
+	v := ToggleBit(0, 3)
+	if v != 8 || !Bit(v, 3) || Bit(v, 2) {
+		t.Errorf("ToggleBit(0, 3) = %d", v)
+	}
+	if v = ToggleBit(v, 3); v != 0 {
+		t.Errorf("toggling bit 3 twice = %d, want 0", v)
+	}
+}
@@ -2,10 +2,7 @@ module calculator
 
 go 1.17
 
-require (
-	fyne.io/fyne/v2 v2.1.1
-	github.com/Knetic/govaluate v3.0.0+incompatible
-)
+require fyne.io/fyne/v2 v2.1.1
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -26,3 +23,7 @@ require (
 	golang.org/x/text v0.3.3 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
+
+require varos v0.0.0
+
+replace varos => ../varos
@@ -0,0 +1,187 @@
+package calculator
+
+import (
+	"fmt"
+	"strings"
+
+	"calculator/expr"
+	"calculator/plot"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// graphCurve reads a line of the graph panel, y = sin(x) * x, f(t) = t^2 or
+// a bare expression in x, into a curve evaluated with the variables of e
+func graphCurve(e *engine, line string) (plot.Curve, error) {
+	variable, body := "x", expr.Node(nil)
+	a, err := expr.ParseAssignment(line, 10)
+	if err != nil {
+		return plot.Curve{}, err
+	}
+	switch {
+	case a == nil:
+		body, err = expr.Parse(line)
+		if err != nil {
+			return plot.Curve{}, err
+		}
+	case a.IsFunction():
+		if len(a.Params) != 1 {
+			return plot.Curve{}, fmt.Errorf("%s needs exactly one variable to be plotted", a.Name)
+		}
+		variable, body = a.Params[0], a.Body
+	default:
+		body = a.Body
+	}
+
+	env := *e.Env
+	env.Vars = make(map[string]float64, len(e.Env.Vars)+1)
+	for name, v := range e.Env.Vars {
+		env.Vars[name] = v
+	}
+	f := func(x float64) (float64, bool) {
+		env.Vars[variable] = x
+		y, err := env.Eval(body)
+		return y, err == nil
+	}
+	if err := checkNames(&env, body, variable); err != nil {
+		return plot.Curve{}, err
+	}
+	return plot.Curve{Label: strings.TrimSpace(line), F: f}, nil
+}
+
+// checkNames fails on the first name in n that env does not know, so a typo
+// is reported instead of plotting nothing
+func checkNames(env *expr.Env, n expr.Node, variable string) error {
+	switch n := n.(type) {
+	case *expr.Ident:
+		if _, ok := env.Vars[n.Name]; ok || n.Name == variable {
+			return nil
+		}
+		if _, ok := env.Consts[n.Name]; !ok {
+			return fmt.Errorf("unknown name %q", n.Name)
+		}
+	case *expr.Call:
+		_, user := env.UserFuncs[n.Name]
+		_, builtin := env.Funcs[n.Name]
+		_, constant := env.Consts[n.Name]
+		_, isVar := env.Vars[n.Name]
+		if !user && !builtin && !constant && !isVar && n.Name != variable {
+			return fmt.Errorf("unknown function %q", n.Name)
+		}
+		for _, arg := range n.Args {
+			if err := checkNames(env, arg, variable); err != nil {
+				return err
+			}
+		}
+	case *expr.Unary:
+		return checkNames(env, n.X, variable)
+	case *expr.Binary:
+		if err := checkNames(env, n.X, variable); err != nil {
+			return err
+		}
+		return checkNames(env, n.Y, variable)
+	case *expr.Paren:
+		return checkNames(env, n.X, variable)
+	}
+	return nil
+}
+
+// graphPanel returns graph mode, a plot of the functions typed one per line
+// with buttons to zoom and to find roots and intersections. The plot follows
+// the variables and functions until stop is called
+func graphPanel(e *engine) (panel fyne.CanvasObject, stop func()) {
+	graph := plot.New()
+	functions := widget.NewMultiLineEntry()
+	functions.SetPlaceHolder("y = sin(x) * x")
+	results := widget.NewLabel("")
+	results.Wrapping = fyne.TextWrapWord
+
+	redraw := func() {
+		names.apply(e)
+		var curves []plot.Curve
+		var errs []string
+		for i, line := range strings.Split(functions.Text, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			c, err := graphCurve(e, line)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("ERROR : line %d: %v", i+1, err))
+				continue
+			}
+			curves = append(curves, c)
+		}
+		graph.SetCurves(curves...)
+		results.SetText(strings.Join(errs, "\n"))
+	}
+	functions.OnChanged = func(string) { redraw() }
+	stop = names.onChanged(redraw)
+
+	rootsBtn := widget.NewButton("Roots", func() {
+		var marks []plot.Point
+		var lines []string
+		for _, c := range graph.Curves() {
+			roots := plot.Roots(c.F, graph.MinX, graph.MaxX)
+			text := make([]string, len(roots))
+			for i, r := range roots {
+				marks = append(marks, r.From)
+				text[i] = expr.Format(r.From.X)
+				if !r.Single() {
+					marks = append(marks, r.To)
+					text[i] += " to " + expr.Format(r.To.X)
+				}
+			}
+			if len(roots) == 0 {
+				text = []string{"none in view"}
+			}
+			lines = append(lines, c.Label+" : x = "+strings.Join(text, ", "))
+		}
+		graph.Mark(marks...)
+		results.SetText(strings.Join(lines, "\n"))
+	})
+	intersectBtn := widget.NewButton("Intersections", func() {
+		curves := graph.Curves()
+		var marks []plot.Point
+		var lines []string
+		for i := range curves {
+			for j := i + 1; j < len(curves); j++ {
+				spans := plot.Intersections(curves[i].F, curves[j].F, graph.MinX, graph.MaxX)
+				text := make([]string, len(spans))
+				for k, s := range spans {
+					marks = append(marks, s.From)
+					text[k] = "(" + expr.Format(s.From.X) + ", " + expr.Format(s.From.Y) + ")"
+					if !s.Single() {
+						marks = append(marks, s.To)
+						text[k] += " to (" + expr.Format(s.To.X) + ", " + expr.Format(s.To.Y) + ")"
+					}
+				}
+				if len(spans) == 0 {
+					text = []string{"none in view"}
+				}
+				lines = append(lines, curves[i].Label+" and "+curves[j].Label+" : "+strings.Join(text, ", "))
+			}
+		}
+		if len(lines) == 0 {
+			lines = []string{"Intersections need two functions"}
+		}
+		graph.Mark(marks...)
+		results.SetText(strings.Join(lines, "\n"))
+	})
+
+	return container.New(
+		ui.BoxLayout("V"),
+		functions,
+		container.NewHBox(
+			widget.NewButtonWithIcon("", theme.ZoomInIcon(), func() { graph.ZoomCenter(0.5) }),
+			widget.NewButtonWithIcon("", theme.ZoomOutIcon(), func() { graph.ZoomCenter(2) }),
+			widget.NewButtonWithIcon("", theme.ViewRestoreIcon(), graph.Reset),
+			rootsBtn,
+			intersectBtn,
+		),
+		graph,
+		results,
+	), stop
+}
@@ -0,0 +1,315 @@
+package calculator
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// historyFile is the history in the storage of the app
+const historyFile = "calculator-history.json"
+
+// historyLimit is how many unpinned records are kept
+const historyLimit = 500
+
+// record is a calculation in the history
+type record struct {
+	Expression string
+	Result     string
+	Time       time.Time
+	Pinned     bool
+}
+
+// history is the calculation history shared by every calculator window
+var history = &historyStore{}
+
+type historyStore struct {
+	mu        sync.Mutex
+	app       fyne.App
+	records   []record
+	loaded    bool
+	listeners map[int]func()
+	nextID    int
+}
+
+// load reads the history from the storage of a the first time it is needed
+func (h *historyStore) load(a fyne.App) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.loaded {
+		return
+	}
+	h.app, h.loaded = a, true
+	reader, err := a.Storage().Open(historyFile)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		fyne.LogError("Failed to read the calculator history", err)
+		return
+	}
+	if err := json.Unmarshal(data, &h.records); err != nil {
+		fyne.LogError("Failed to read the calculator history", err)
+	}
+}
+
+// save writes the history, h.mu must be held
+func (h *historyStore) save() {
+	data, err := json.Marshal(h.records)
+	if err != nil {
+		fyne.LogError("Failed to save the calculator history", err)
+		return
+	}
+	writer, err := h.app.Storage().Save(historyFile)
+	if err != nil {
+		writer, err = h.app.Storage().Create(historyFile)
+		if err != nil {
+			fyne.LogError("Failed to save the calculator history", err)
+			return
+		}
+	}
+	defer writer.Close()
+	if _, err := writer.Write(data); err != nil {
+		fyne.LogError("Failed to save the calculator history", err)
+	}
+}
+
+// change applies f to the records, saves them and tells the listeners
+func (h *historyStore) change(f func()) {
+	h.mu.Lock()
+	f()
+	h.save()
+	listeners := make([]func(), 0, len(h.listeners))
+	for _, l := range h.listeners {
+		listeners = append(listeners, l)
+	}
+	h.mu.Unlock()
+	for _, l := range listeners {
+		l()
+	}
+}
+
+// add records a calculation
+func (h *historyStore) add(expression, result string) {
+	h.change(func() {
+		h.records = append(h.records, record{Expression: expression, Result: result, Time: time.Now()})
+		unpinned := 0
+		for _, r := range h.records {
+			if !r.Pinned {
+				unpinned++
+			}
+		}
+		for i := 0; unpinned > historyLimit && i < len(h.records); {
+			if h.records[i].Pinned {
+				i++
+				continue
+			}
+			h.records = append(h.records[:i], h.records[i+1:]...)
+			unpinned--
+		}
+	})
+}
+
+// remove deletes the record made at t
+func (h *historyStore) remove(t time.Time) {
+	h.change(func() {
+		for i, r := range h.records {
+			if r.Time.Equal(t) {
+				h.records = append(h.records[:i], h.records[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// togglePin pins or unpins the record made at t
+func (h *historyStore) togglePin(t time.Time) {
+	h.change(func() {
+		for i, r := range h.records {
+			if r.Time.Equal(t) {
+				h.records[i].Pinned = !r.Pinned
+				return
+			}
+		}
+	})
+}
+
+// clear deletes every record that is not pinned
+func (h *historyStore) clear() {
+	h.change(func() {
+		pinned := make([]record, 0)
+		for _, r := range h.records {
+			if r.Pinned {
+				pinned = append(pinned, r)
+			}
+		}
+		h.records = pinned
+	})
+}
+
+// search returns the records whose expression or result contains query,
+// pinned records first and then the newest first
+func (h *historyStore) search(query string) []record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	query = strings.ToLower(strings.TrimSpace(query))
+	found := make([]record, 0)
+	for _, r := range h.records {
+		if query == "" || strings.Contains(strings.ToLower(r.Expression), query) || strings.Contains(strings.ToLower(r.Result), query) {
+			found = append(found, r)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Pinned != found[j].Pinned {
+			return found[i].Pinned
+		}
+		return found[i].Time.After(found[j].Time)
+	})
+	return found
+}
+
+// expressions returns the expressions typed, the newest first
+func (h *historyStore) expressions() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	list := make([]string, len(h.records))
+	for i, r := range h.records {
+		list[len(list)-1-i] = r.Expression
+	}
+	return list
+}
+
+// onChanged registers f to be called whenever the history changes until
+// the returned func is called
+func (h *historyStore) onChanged(f func()) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.listeners == nil {
+		h.listeners = make(map[int]func())
+	}
+	h.nextID++
+	id := h.nextID
+	h.listeners[id] = f
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.listeners, id)
+	}
+}
+
+// exportCSV writes the history to w, oldest first
+func (h *historyStore) exportCSV(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := csv.NewWriter(w)
+	out.Write([]string{"expression", "result", "time", "pinned"})
+	for _, r := range h.records {
+		out.Write([]string{r.Expression, r.Result, r.Time.Format(time.RFC3339), strconv.FormatBool(r.Pinned)})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// historyPanel returns the searchable history list, tapping a record puts
+// its expression back into input. The list follows the history until stop
+// is called
+func historyPanel(w fyne.Window, input *widget.Entry) (panel fyne.CanvasObject, stop func()) {
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search history")
+	records := history.search("")
+
+	list := widget.NewList(
+		func() int { return len(records) },
+		func() fyne.CanvasObject {
+			when := widget.NewLabel("")
+			when.TextStyle = fyne.TextStyle{Italic: true}
+			return container.NewHBox(
+				widget.NewLabel(""),
+				layout.NewSpacer(),
+				when,
+				widget.NewButton("Pin", nil),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			r := records[id]
+			row := item.(*fyne.Container)
+			if r.Result == "" {
+				row.Objects[0].(*widget.Label).SetText(r.Expression)
+			} else {
+				row.Objects[0].(*widget.Label).SetText(r.Expression + " = " + r.Result)
+			}
+			row.Objects[2].(*widget.Label).SetText(r.Time.Format("Jan 2 15:04"))
+			pin := row.Objects[3].(*widget.Button)
+			pin.SetText("Pin")
+			if r.Pinned {
+				pin.SetText("Unpin")
+			}
+			pin.OnTapped = func() { history.togglePin(r.Time) }
+			row.Objects[4].(*widget.Button).OnTapped = func() { history.remove(r.Time) }
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		input.SetText(records[id].Expression)
+		list.Unselect(id)
+	}
+
+	refresh := func() {
+		records = history.search(search.Text)
+		list.Refresh()
+	}
+	search.OnChanged = func(_ string) { refresh() }
+	stop = history.onChanged(refresh)
+
+	exportBtn := widget.NewButtonWithIcon("Export", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := history.exportCSV(writer); err != nil {
+				ui.ShowError(err, w)
+			}
+		}, w)
+		save.SetFileName("calculator-history.csv")
+		save.Show()
+	})
+	clearBtn := widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), func() {
+		dialog.ShowConfirm("Clear History", "Delete every calculation that is not pinned?", func(ok bool) {
+			if ok {
+				history.clear()
+			}
+		}, w)
+	})
+
+	// The list has no height of its own in the box layout of the calculator
+	size := canvas.NewRectangle(color.Transparent)
+	size.SetMinSize(fyne.NewSize(0, 180))
+	return container.NewBorder(
+		container.NewBorder(nil, nil, nil, container.NewHBox(exportBtn, clearBtn), search),
+		nil, nil, nil,
+		container.NewMax(size, list),
+	), stop
+}
@@ -0,0 +1,264 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// keyPref prefixes the preference holding the keys of an action
+const keyPref = "calculator.keys."
+
+// keyAction is something the keyboard can do in the calculator
+type keyAction struct {
+	Name  string
+	Label string
+	// Keys are the default keys, like Return or Ctrl+H
+	Keys string
+}
+
+// keyActions are the actions that can be given keys in the settings
+var keyActions = []keyAction{
+	{"evaluate", "Evaluate", "Return, KP_Enter"},
+	{"clear", "Clear", "Escape"},
+	{"back", "Back", "BackSpace"},
+	{"previous", "Previous in history", "Up"},
+	{"next", "Next in history", "Down"},
+	{"history", "Show history", "Ctrl+H"},
+	{"variables", "Show variables", "Ctrl+L"},
+	{"explain", "Explain", "Ctrl+E"},
+	{"mode", "Next mode", "Ctrl+M"},
+}
+
+// modifierNames are the modifiers as written in a key
+var modifierNames = []struct {
+	name     string
+	modifier desktop.Modifier
+}{
+	{"Ctrl", desktop.ControlModifier},
+	{"Alt", desktop.AltModifier},
+	{"Super", desktop.SuperModifier},
+}
+
+// keyName writes a key with its modifiers, like Ctrl+H
+func keyName(key fyne.KeyName, modifier desktop.Modifier) string {
+	name := string(key)
+	for i := len(modifierNames) - 1; i >= 0; i-- {
+		if modifier&modifierNames[i].modifier != 0 {
+			name = modifierNames[i].name + "+" + name
+		}
+	}
+	return name
+}
+
+// parseKeys reads a comma separated list of keys, like Return, Ctrl+H, into
+// their names as written by keyName
+func parseKeys(s string) ([]string, error) {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		parts := strings.Split(key, "+")
+		var modifier desktop.Modifier
+		for _, part := range parts[:len(parts)-1] {
+			found := false
+			for _, m := range modifierNames {
+				if strings.EqualFold(part, m.name) {
+					modifier |= m.modifier
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown modifier %q in %q, use Ctrl, Alt or Super", part, key)
+			}
+		}
+		name := parts[len(parts)-1]
+		if name == "" {
+			return nil, fmt.Errorf("%q needs a key after the +", key)
+		}
+		if len(name) == 1 {
+			name = strings.ToUpper(name)
+		}
+		keys = append(keys, keyName(fyne.KeyName(name), modifier))
+	}
+	return keys, nil
+}
+
+// keymap tells which action each key does
+type keymap map[string]string
+
+// loadKeymap reads the keys of every action from the preferences
+func loadKeymap(a fyne.App) keymap {
+	m := make(keymap)
+	for _, action := range keyActions {
+		keys, err := parseKeys(a.Preferences().StringWithFallback(keyPref+action.Name, action.Keys))
+		if err != nil {
+			keys, _ = parseKeys(action.Keys)
+		}
+		for _, key := range keys {
+			m[key] = action.Name
+		}
+	}
+	return m
+}
+
+// keys returns the keys of action as shown in the settings
+func (m keymap) keys(action string) string {
+	var keys []string
+	for key, name := range m {
+		if name == action {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// keyboard runs the actions of the keys typed in a calculator window, both
+// in its input and when nothing has the focus
+type keyboard struct {
+	window  fyne.Window
+	input   *calcEntry
+	keys    keymap
+	actions map[string]func()
+
+	// walk is the position in the history while walking it, draft the text
+	// typed before walking
+	walk  int
+	draft string
+}
+
+func newKeyboard(a fyne.App, w fyne.Window, input *calcEntry) *keyboard {
+	k := &keyboard{window: w, input: input, keys: loadKeymap(a), actions: make(map[string]func()), walk: -1}
+	input.keyboard = k
+	w.Canvas().SetOnTypedKey(func(key *fyne.KeyEvent) {
+		if !k.typed(keyName(key.Name, 0)) {
+			w.Canvas().Focus(input)
+			input.TypedKey(key)
+		}
+	})
+	w.Canvas().SetOnTypedRune(func(r rune) {
+		w.Canvas().Focus(input)
+		input.TypedRune(r)
+	})
+	k.addShortcuts()
+	return k
+}
+
+// handle sets what action does
+func (k *keyboard) handle(action string, f func()) {
+	k.actions[action] = f
+}
+
+// typed runs the action of key, it returns false if key has none
+func (k *keyboard) typed(key string) bool {
+	f, ok := k.actions[k.keys[key]]
+	if !ok {
+		return false
+	}
+	if action := k.keys[key]; action != "previous" && action != "next" {
+		k.walk = -1
+	}
+	f()
+	return true
+}
+
+// addShortcuts gives the window the keys with modifiers, used when nothing
+// has the focus
+func (k *keyboard) addShortcuts() {
+	for key := range k.keys {
+		if shortcut := k.shortcut(key); shortcut != nil {
+			name := key
+			k.window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) { k.typed(name) })
+		}
+	}
+}
+
+// shortcut returns key as a shortcut, or nil for a key without modifiers
+func (k *keyboard) shortcut(key string) *desktop.CustomShortcut {
+	parts := strings.Split(key, "+")
+	if len(parts) < 2 {
+		return nil
+	}
+	s := &desktop.CustomShortcut{KeyName: fyne.KeyName(parts[len(parts)-1])}
+	for _, part := range parts[:len(parts)-1] {
+		for _, m := range modifierNames {
+			if part == m.name {
+				s.Modifier |= m.modifier
+			}
+		}
+	}
+	return s
+}
+
+// setKeys replaces the keymap, keeping it in the preferences
+func (k *keyboard) setKeys(a fyne.App, keys map[string]string) error {
+	for action, list := range keys {
+		if _, err := parseKeys(list); err != nil {
+			return err
+		}
+		a.Preferences().SetString(keyPref+action, list)
+	}
+	for key := range k.keys {
+		if shortcut := k.shortcut(key); shortcut != nil {
+			k.window.Canvas().RemoveShortcut(shortcut)
+		}
+	}
+	k.keys = loadKeymap(a)
+	k.addShortcuts()
+	return nil
+}
+
+// step walks the history, older for 1 and newer for -1, keeping what was
+// typed to come back to
+func (k *keyboard) step(by int) {
+	list := history.expressions()
+	if k.walk < 0 {
+		k.draft = k.input.Text
+	}
+	walk := k.walk + by
+	if walk >= len(list) {
+		return
+	}
+	k.walk = walk
+	if walk < 0 {
+		k.walk = -1
+		k.input.SetText(k.draft)
+	} else {
+		k.input.SetText(list[walk])
+	}
+	k.input.CursorColumn = len([]rune(k.input.Text))
+	k.input.Refresh()
+}
+
+// calcEntry is the input of the calculator, passing the keys of the keymap
+// to the keyboard
+type calcEntry struct {
+	widget.Entry
+	keyboard *keyboard
+}
+
+func newCalcEntry() *calcEntry {
+	e := &calcEntry{}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *calcEntry) TypedKey(key *fyne.KeyEvent) {
+	if e.keyboard == nil || !e.keyboard.typed(keyName(key.Name, 0)) {
+		e.Entry.TypedKey(key)
+	}
+}
+
+func (e *calcEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if s, ok := shortcut.(*desktop.CustomShortcut); ok && e.keyboard != nil && e.keyboard.typed(keyName(s.KeyName, s.Modifier)) {
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
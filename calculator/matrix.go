@@ -0,0 +1,221 @@
+package calculator
+
+import (
+	"fmt"
+	"strconv"
+
+	"calculator/expr"
+	"calculator/matrix"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// matrixSizes offered for the rows and columns of a matrix
+var matrixSizes = []string{"1", "2", "3", "4", "5", "6"}
+
+// matrixEditor is a grid of entries holding a matrix, each entry may be an
+// expression like pi/2 or x
+type matrixEditor struct {
+	name    string
+	rows    *widget.Select
+	cols    *widget.Select
+	grid    *fyne.Container
+	entries [][]*widget.Entry
+	content fyne.CanvasObject
+}
+
+func newMatrixEditor(name string, rows, cols int) *matrixEditor {
+	m := &matrixEditor{name: name, grid: container.New(ui.GridLayout("C", cols))}
+	resize := func(string) {
+		r, _ := strconv.Atoi(m.rows.Selected)
+		c, _ := strconv.Atoi(m.cols.Selected)
+		m.resize(r, c)
+	}
+	m.rows = widget.NewSelect(matrixSizes, resize)
+	m.cols = widget.NewSelect(matrixSizes, resize)
+	m.rows.Selected, m.cols.Selected = strconv.Itoa(rows), strconv.Itoa(cols)
+	m.resize(rows, cols)
+	m.content = container.New(
+		ui.BoxLayout("V"),
+		container.NewHBox(widget.NewLabel(name), m.rows, widget.NewLabel("×"), m.cols),
+		m.grid,
+	)
+	return m
+}
+
+// resize changes the size of the grid, keeping the entries that still fit
+func (m *matrixEditor) resize(rows, cols int) {
+	entries := make([][]*widget.Entry, rows)
+	m.grid.Objects = nil
+	for i := range entries {
+		entries[i] = make([]*widget.Entry, cols)
+		for j := range entries[i] {
+			e := widget.NewEntry()
+			e.SetPlaceHolder("0")
+			if i < len(m.entries) && j < len(m.entries[i]) {
+				e.SetText(m.entries[i][j].Text)
+			}
+			entries[i][j] = e
+			m.grid.Add(e)
+		}
+	}
+	m.entries = entries
+	m.grid.Layout = ui.GridLayout("C", cols)
+	m.grid.Refresh()
+}
+
+// read evaluates the entries into a matrix, empty entries are 0
+func (m *matrixEditor) read(e *engine) (*matrix.Matrix, error) {
+	a := matrix.New(len(m.entries), len(m.entries[0]))
+	for i, row := range m.entries {
+		for j, entry := range row {
+			if entry.Text == "" {
+				continue
+			}
+			v, err := e.value(entry.Text)
+			if err != nil {
+				return nil, fmt.Errorf("%s row %d column %d: %w", m.name, i+1, j+1, err)
+			}
+			a.Set(i, j, v)
+		}
+	}
+	return a, nil
+}
+
+// set shows a in the grid
+func (m *matrixEditor) set(a *matrix.Matrix) {
+	m.rows.Selected, m.cols.Selected = strconv.Itoa(a.Rows), strconv.Itoa(a.Cols)
+	m.rows.Refresh()
+	m.cols.Refresh()
+	m.resize(a.Rows, a.Cols)
+	for i, row := range m.entries {
+		for j, entry := range row {
+			entry.SetText(expr.Format(a.At(i, j)))
+		}
+	}
+}
+
+// matrixPanel returns matrix mode, two matrices A and B with the operations
+// on them. Tapping an entry of a result inserts it into the input
+func matrixPanel(e *engine, insert func(string)) fyne.CanvasObject {
+	a := newMatrixEditor("A", 2, 2)
+	b := newMatrixEditor("B", 2, 2)
+	message := widget.NewLabel("")
+	resultGrid := container.New(ui.GridLayout("C", 1))
+	var result *matrix.Matrix
+
+	show := func(r *matrix.Matrix, err error) {
+		result = nil
+		resultGrid.Objects = nil
+		if err != nil {
+			message.SetText("ERROR : " + err.Error())
+			resultGrid.Refresh()
+			return
+		}
+		result = r
+		message.SetText("Result " + r.Size() + ", tap an entry to insert it")
+		resultGrid.Layout = ui.GridLayout("C", r.Cols)
+		for _, v := range r.Data {
+			text := expr.Format(v)
+			resultGrid.Add(widget.NewButton(text, func() { insert(text) }))
+		}
+		resultGrid.Refresh()
+	}
+	showScalar := func(name, value string) {
+		result = nil
+		resultGrid.Objects = nil
+		resultGrid.Layout = ui.GridLayout("C", 1)
+		resultGrid.Add(widget.NewButton(value, func() { insert(value) }))
+		resultGrid.Refresh()
+		message.SetText(name + " = " + value + ", tap it to insert it")
+	}
+
+	// Operations read the grids when they are used
+	unary := func(f func(x *matrix.Matrix) (*matrix.Matrix, error)) func() {
+		return func() {
+			x, err := a.read(e)
+			if err != nil {
+				show(nil, err)
+				return
+			}
+			show(f(x))
+		}
+	}
+	binary := func(f func(x, y *matrix.Matrix) (*matrix.Matrix, error)) func() {
+		return func() {
+			x, err := a.read(e)
+			if err != nil {
+				show(nil, err)
+				return
+			}
+			y, err := b.read(e)
+			if err != nil {
+				show(nil, err)
+				return
+			}
+			show(f(x, y))
+		}
+	}
+
+	operations := container.New(
+		ui.GridLayout("C", 4),
+		widget.NewButton("A + B", binary((*matrix.Matrix).Add)),
+		widget.NewButton("A − B", binary((*matrix.Matrix).Sub)),
+		widget.NewButton("A × B", binary((*matrix.Matrix).Mul)),
+		widget.NewButton("Solve AX = B", binary((*matrix.Matrix).Solve)),
+		widget.NewButton("Aᵀ", unary(func(x *matrix.Matrix) (*matrix.Matrix, error) { return x.Transpose(), nil })),
+		widget.NewButton("A⁻¹", unary((*matrix.Matrix).Inverse)),
+		widget.NewButton("det A", func() {
+			x, err := a.read(e)
+			if err == nil {
+				var det float64
+				if det, err = x.Det(); err == nil {
+					showScalar("det A", expr.Format(det))
+					return
+				}
+			}
+			show(nil, err)
+		}),
+		widget.NewButton("rank A", func() {
+			x, err := a.read(e)
+			if err != nil {
+				show(nil, err)
+				return
+			}
+			showScalar("rank A", strconv.Itoa(x.Rank()))
+		}),
+	)
+	keep := container.NewHBox(
+		widget.NewButton("Result → A", func() {
+			if result != nil {
+				a.set(result)
+			}
+		}),
+		widget.NewButton("Result → B", func() {
+			if result != nil {
+				b.set(result)
+			}
+		}),
+		widget.NewButton("Swap A, B", func() {
+			x, errA := a.read(e)
+			y, errB := b.read(e)
+			if errA != nil || errB != nil {
+				show(nil, fmt.Errorf("both matrices must be valid to swap them"))
+				return
+			}
+			a.set(y)
+			b.set(x)
+		}),
+	)
+
+	return container.New(
+		ui.BoxLayout("V"),
+		container.New(ui.GridLayout("C", 2), a.content, b.content),
+		operations,
+		keep,
+		message,
+		resultGrid,
+	)
+}
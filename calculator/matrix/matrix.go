@@ -0,0 +1,257 @@
+// Package matrix does the linear algebra of the calculator's matrix mode on
+// small dense matrices of float64.
+package matrix
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// epsilon is how close to 0 a pivot may be before the matrix is singular,
+// relative to the largest entry of the matrix
+const epsilon = 1e-12
+
+// ErrSingular is returned when a matrix has no inverse
+var ErrSingular = errors.New("the matrix is singular")
+
+// Matrix has Rows × Cols entries stored row by row
+type Matrix struct {
+	Rows, Cols int
+	Data       []float64
+}
+
+// New returns a zero matrix
+func New(rows, cols int) *Matrix {
+	return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+// Identity returns the n × n identity matrix
+func Identity(n int) *Matrix {
+	m := New(n, n)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+// At returns the entry in row i and column j, counted from 0
+func (m *Matrix) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set changes the entry in row i and column j, counted from 0
+func (m *Matrix) Set(i, j int, v float64) {
+	m.Data[i*m.Cols+j] = v
+}
+
+// Size returns the size as written in errors, like 2×3
+func (m *Matrix) Size() string {
+	return fmt.Sprintf("%d×%d", m.Rows, m.Cols)
+}
+
+// Copy returns a copy of m
+func (m *Matrix) Copy() *Matrix {
+	c := New(m.Rows, m.Cols)
+	copy(c.Data, m.Data)
+	return c
+}
+
+// Add returns m + n
+func (m *Matrix) Add(n *Matrix) (*Matrix, error) {
+	return m.combine(n, "added", func(a, b float64) float64 { return a + b })
+}
+
+// Sub returns m - n
+func (m *Matrix) Sub(n *Matrix) (*Matrix, error) {
+	return m.combine(n, "subtracted", func(a, b float64) float64 { return a - b })
+}
+
+func (m *Matrix) combine(n *Matrix, verb string, f func(a, b float64) float64) (*Matrix, error) {
+	if m.Rows != n.Rows || m.Cols != n.Cols {
+		return nil, fmt.Errorf("a %s and a %s matrix cannot be %s", m.Size(), n.Size(), verb)
+	}
+	r := New(m.Rows, m.Cols)
+	for i := range r.Data {
+		r.Data[i] = f(m.Data[i], n.Data[i])
+	}
+	return r, nil
+}
+
+// Mul returns the product m n
+func (m *Matrix) Mul(n *Matrix) (*Matrix, error) {
+	if m.Cols != n.Rows {
+		return nil, fmt.Errorf("a %s matrix cannot multiply a %s one, the columns of the first must match the rows of the second", m.Size(), n.Size())
+	}
+	r := New(m.Rows, n.Cols)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < n.Cols; j++ {
+			sum := 0.0
+			for k := 0; k < m.Cols; k++ {
+				sum += m.At(i, k) * n.At(k, j)
+			}
+			r.Set(i, j, sum)
+		}
+	}
+	return r, nil
+}
+
+// Scale returns m with every entry multiplied by k
+func (m *Matrix) Scale(k float64) *Matrix {
+	r := m.Copy()
+	for i := range r.Data {
+		r.Data[i] *= k
+	}
+	return r
+}
+
+// Transpose returns m with its rows and columns swapped
+func (m *Matrix) Transpose() *Matrix {
+	r := New(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			r.Set(j, i, m.At(i, j))
+		}
+	}
+	return r
+}
+
+// Det returns the determinant of a square matrix
+func (m *Matrix) Det() (float64, error) {
+	if m.Rows != m.Cols {
+		return 0, fmt.Errorf("a %s matrix has no determinant, it must be square", m.Size())
+	}
+	r := m.Copy()
+	det := 1.0
+	tolerance := epsilon * m.norm()
+	for col := 0; col < r.Cols; col++ {
+		pivot := r.pivot(col, col, tolerance)
+		if pivot < 0 {
+			return 0, nil
+		}
+		if pivot != col {
+			r.swap(pivot, col)
+			det = -det
+		}
+		det *= r.At(col, col)
+		r.eliminate(col, col, false)
+	}
+	return det, nil
+}
+
+// Inverse returns the inverse of a square matrix
+func (m *Matrix) Inverse() (*Matrix, error) {
+	if m.Rows != m.Cols {
+		return nil, fmt.Errorf("a %s matrix has no inverse, it must be square", m.Size())
+	}
+	return m.Solve(Identity(m.Rows))
+}
+
+// Rank returns the number of linearly independent rows
+func (m *Matrix) Rank() int {
+	r := m.Copy()
+	rank := 0
+	tolerance := epsilon * m.norm()
+	for col := 0; col < r.Cols && rank < r.Rows; col++ {
+		pivot := r.pivot(rank, col, tolerance)
+		if pivot < 0 {
+			continue
+		}
+		r.swap(pivot, rank)
+		r.eliminate(rank, col, false)
+		rank++
+	}
+	return rank
+}
+
+// Solve returns x with m x = b for a square m, each column of b is a right
+// hand side
+func (m *Matrix) Solve(b *Matrix) (*Matrix, error) {
+	if m.Rows != m.Cols {
+		return nil, fmt.Errorf("a %s system cannot be solved, the matrix must be square", m.Size())
+	}
+	if b.Rows != m.Rows {
+		return nil, fmt.Errorf("the right hand side has %d rows, it needs %d", b.Rows, m.Rows)
+	}
+	// Gauss-Jordan elimination on m augmented with b
+	n := m.Rows
+	r := New(n, n+b.Cols)
+	for i := 0; i < n; i++ {
+		copy(r.Data[i*r.Cols:], m.Data[i*n:(i+1)*n])
+		copy(r.Data[i*r.Cols+n:], b.Data[i*b.Cols:(i+1)*b.Cols])
+	}
+	// The tolerance follows m alone, b may be much larger or smaller
+	tolerance := epsilon * m.norm()
+	for col := 0; col < n; col++ {
+		pivot := r.pivot(col, col, tolerance)
+		if pivot < 0 {
+			return nil, ErrSingular
+		}
+		r.swap(pivot, col)
+		r.eliminate(col, col, true)
+	}
+	x := New(n, b.Cols)
+	for i := 0; i < n; i++ {
+		copy(x.Data[i*b.Cols:], r.Data[i*r.Cols+n:(i+1)*r.Cols])
+	}
+	return x, nil
+}
+
+// pivot returns the row from start on with the largest entry in col, or -1
+// if none is above tolerance
+func (m *Matrix) pivot(start, col int, tolerance float64) int {
+	best, row := 0.0, -1
+	for i := start; i < m.Rows; i++ {
+		if v := math.Abs(m.At(i, col)); v > best {
+			best, row = v, i
+		}
+	}
+	if row < 0 || best <= tolerance {
+		return -1
+	}
+	return row
+}
+
+// norm returns the largest absolute entry
+func (m *Matrix) norm() float64 {
+	max := 0.0
+	for _, v := range m.Data {
+		max = math.Max(max, math.Abs(v))
+	}
+	return max
+}
+
+func (m *Matrix) swap(i, j int) {
+	if i == j {
+		return
+	}
+	for k := 0; k < m.Cols; k++ {
+		a, b := m.At(i, k), m.At(j, k)
+		m.Set(i, k, b)
+		m.Set(j, k, a)
+	}
+}
+
+// eliminate clears col below the pivot row, or in every other row when all
+// is set, which also scales the pivot row to 1
+func (m *Matrix) eliminate(row, col int, all bool) {
+	p := m.At(row, col)
+	if all {
+		for k := 0; k < m.Cols; k++ {
+			m.Set(row, k, m.At(row, k)/p)
+		}
+		p = 1
+	}
+	for i := 0; i < m.Rows; i++ {
+		if i == row || (!all && i < row) {
+			continue
+		}
+		f := m.At(i, col) / p
+		if f == 0 {
+			continue
+		}
+		for k := col; k < m.Cols; k++ {
+			m.Set(i, k, m.At(i, k)-f*m.At(row, k))
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package matrix
+
+import (
+	"math"
+	"testing"
+)
+
+// rows builds a matrix from its rows
+func rows(r ...[]float64) *Matrix {
+	m := New(len(r), len(r[0]))
+	for i := range r {
+		copy(m.Data[i*m.Cols:], r[i])
+	}
+	return m
+}
+
+// near reports whether m and n have the same size and about the same entries
+func near(m, n *Matrix) bool {
+	if m.Rows != n.Rows || m.Cols != n.Cols {
+		return false
+	}
+	for i := range m.Data {
+		if math.Abs(m.Data[i]-n.Data[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDet(t *testing.T) {
+	tests := []struct {
+		m    *Matrix
+		want float64
+	}{
+		{rows([]float64{3}), 3},
+		{rows([]float64{1, 2}, []float64{3, 4}), -2},
+		{rows([]float64{0, 1}, []float64{1, 0}), -1},
+		{rows([]float64{2, 0, 1}, []float64{1, 3, 2}, []float64{1, 1, 2}), 6},
+		{rows([]float64{1, 2}, []float64{2, 4}), 0},
+	}
+	for _, test := range tests {
+		got, err := test.m.Det()
+		if err != nil {
+			t.Errorf("det %v: %v", test.m.Data, err)
+			continue
+		}
+		if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("det %v = %v, want %v", test.m.Data, got, test.want)
+		}
+	}
+	if _, err := New(2, 3).Det(); err == nil {
+		t.Error("det of a 2×3 matrix: no error")
+	}
+}
+
+func TestInverse(t *testing.T) {
+	m := rows([]float64{4, 7}, []float64{2, 6})
+	inv, err := m.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rows([]float64{0.6, -0.7}, []float64{-0.2, 0.4})
+	if !near(inv, want) {
+		t.Errorf("inverse = %v, want %v", inv.Data, want.Data)
+	}
+	if p, _ := m.Mul(inv); !near(p, Identity(2)) {
+		t.Errorf("m m⁻¹ = %v, want the identity", p.Data)
+	}
+	if _, err := rows([]float64{1, 2}, []float64{2, 4}).Inverse(); err != ErrSingular {
+		t.Errorf("inverse of a singular matrix: %v, want ErrSingular", err)
+	}
+}
+
+func TestSolve(t *testing.T) {
+	// x + y = 3, 2x - y = 0
+	m := rows([]float64{1, 1}, []float64{2, -1})
+	x, err := m.Solve(rows([]float64{3}, []float64{0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := rows([]float64{1}, []float64{2}); !near(x, want) {
+		t.Errorf("solution = %v, want %v", x.Data, want.Data)
+	}
+	if _, err := m.Solve(New(3, 1)); err == nil {
+		t.Error("solve with 3 rows on the right: no error")
+	}
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		m    *Matrix
+		want int
+	}{
+		{Identity(3), 3},
+		{New(2, 2), 0},
+		{rows([]float64{1, 2, 3}, []float64{2, 4, 6}), 1},
+		{rows([]float64{1, 2}, []float64{3, 4}, []float64{5, 6}), 2},
+	}
+	for _, test := range tests {
+		if got := test.m.Rank(); got != test.want {
+			t.Errorf("rank %v = %d, want %d", test.m.Data, got, test.want)
+		}
+	}
+}
+
+func TestSmallEntries(t *testing.T) {
+	// Small but well conditioned, only the scale of the entries is small
+	m := rows([]float64{1e-13, 0}, []float64{0, 1e-13})
+	if det, err := m.Det(); err != nil || math.Abs(det-1e-26) > 1e-38 {
+		t.Errorf("det = %v, %v, want 1e-26", det, err)
+	}
+	if rank := m.Rank(); rank != 2 {
+		t.Errorf("rank = %d, want 2", rank)
+	}
+	inv, err := m.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := m.Mul(inv); !near(p, Identity(2)) {
+		t.Errorf("m m⁻¹ = %v, want the identity", p.Data)
+	}
+	// Singular whatever the scale
+	if _, err := rows([]float64{1e-13, 2e-13}, []float64{2e-13, 4e-13}).Inverse(); err != ErrSingular {
+		t.Errorf("inverse of a small singular matrix: %v, want ErrSingular", err)
+	}
+}
+
+func TestSizes(t *testing.T) {
+	a, b := New(2, 3), New(3, 2)
+	if _, err := a.Add(b); err == nil {
+		t.Error("2×3 + 3×2: no error")
+	}
+	p, err := a.Mul(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Size() != "2×2" {
+		t.Errorf("2×3 times 3×2 is %s, want 2×2", p.Size())
+	}
+	if _, err := a.Mul(a); err == nil {
+		t.Error("2×3 times 2×3: no error")
+	}
+	if tr := a.Transpose(); tr.Size() != "3×2" {
+		t.Errorf("transpose of 2×3 is %s, want 3×2", tr.Size())
+	}
+}
@@ -0,0 +1,343 @@
+// Package plot draws functions of x on a canvas that can be panned, zoomed
+// and traced, and finds their roots and intersections.
+package plot
+
+import (
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Func is a function to plot, ok is false where it is undefined
+type Func func(x float64) (y float64, ok bool)
+
+// Curve is a function drawn on the plot
+type Curve struct {
+	Label string
+	F     Func
+}
+
+// Colors of the curves, in order
+var Colors = []color.Color{
+	color.NRGBA{R: 0x21, G: 0x96, B: 0xf3, A: 0xff},
+	color.NRGBA{R: 0xf4, G: 0x43, B: 0x36, A: 0xff},
+	color.NRGBA{R: 0x4c, G: 0xaf, B: 0x50, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0x98, B: 0x00, A: 0xff},
+	color.NRGBA{R: 0x9c, G: 0x27, B: 0xb0, A: 0xff},
+	color.NRGBA{R: 0x00, G: 0x96, B: 0x88, A: 0xff},
+}
+
+// Plot is a widget drawing curves over a grid with labelled axes. Dragging
+// pans, scrolling zooms and the pointer traces the first curve
+type Plot struct {
+	widget.BaseWidget
+
+	MinX, MaxX, MinY, MaxY float64
+
+	curves []Curve
+	marks  []Point
+
+	tracing bool
+	traceX  float32
+}
+
+// New returns a plot of curves showing -10 to 10 on both axes
+func New(curves ...Curve) *Plot {
+	p := &Plot{curves: curves}
+	p.Reset()
+	p.ExtendBaseWidget(p)
+	return p
+}
+
+// SetCurves replaces the curves and the marked points
+func (p *Plot) SetCurves(curves ...Curve) {
+	p.curves = curves
+	p.marks = nil
+	p.Refresh()
+}
+
+// Curves returns the curves of the plot
+func (p *Plot) Curves() []Curve {
+	return p.curves
+}
+
+// Mark highlights points, like roots, on the plot
+func (p *Plot) Mark(points ...Point) {
+	p.marks = points
+	p.Refresh()
+}
+
+// Reset shows -10 to 10 on both axes
+func (p *Plot) Reset() {
+	p.MinX, p.MaxX, p.MinY, p.MaxY = -10, 10, -10, 10
+	p.Refresh()
+}
+
+// Zoom scales the view by factor around the plot coordinates x, y, factors
+// below 1 zoom in
+func (p *Plot) Zoom(factor, x, y float64) {
+	p.MinX = x + (p.MinX-x)*factor
+	p.MaxX = x + (p.MaxX-x)*factor
+	p.MinY = y + (p.MinY-y)*factor
+	p.MaxY = y + (p.MaxY-y)*factor
+	p.Refresh()
+}
+
+// ZoomCenter scales the view by factor around its center
+func (p *Plot) ZoomCenter(factor float64) {
+	p.Zoom(factor, (p.MinX+p.MaxX)/2, (p.MinY+p.MaxY)/2)
+}
+
+// toPlot converts a position on the widget to plot coordinates
+func (p *Plot) toPlot(pos fyne.Position) (float64, float64) {
+	size := p.Size()
+	x := p.MinX + float64(pos.X/size.Width)*(p.MaxX-p.MinX)
+	y := p.MaxY - float64(pos.Y/size.Height)*(p.MaxY-p.MinY)
+	return x, y
+}
+
+// toScreen converts plot coordinates to a position on the widget
+func (p *Plot) toScreen(x, y float64) fyne.Position {
+	size := p.Size()
+	return fyne.NewPos(
+		float32((x-p.MinX)/(p.MaxX-p.MinX))*size.Width,
+		float32((p.MaxY-y)/(p.MaxY-p.MinY))*size.Height,
+	)
+}
+
+// Dragged pans the view
+func (p *Plot) Dragged(e *fyne.DragEvent) {
+	size := p.Size()
+	dx := float64(e.Dragged.DX/size.Width) * (p.MaxX - p.MinX)
+	dy := float64(e.Dragged.DY/size.Height) * (p.MaxY - p.MinY)
+	p.MinX, p.MaxX = p.MinX-dx, p.MaxX-dx
+	p.MinY, p.MaxY = p.MinY+dy, p.MaxY+dy
+	p.traceX = e.Position.X
+	p.Refresh()
+}
+
+// DragEnd is called when panning ends
+func (p *Plot) DragEnd() {
+}
+
+// Scrolled zooms around the pointer
+func (p *Plot) Scrolled(e *fyne.ScrollEvent) {
+	x, y := p.toPlot(e.Position)
+	factor := math.Pow(0.9, float64(e.Scrolled.DY)/10)
+	p.Zoom(factor, x, y)
+}
+
+// MouseIn starts tracing
+func (p *Plot) MouseIn(e *desktop.MouseEvent) {
+	p.tracing = true
+	p.MouseMoved(e)
+}
+
+// MouseMoved moves the trace cursor
+func (p *Plot) MouseMoved(e *desktop.MouseEvent) {
+	p.traceX = e.Position.X
+	p.Refresh()
+}
+
+// MouseOut hides the trace cursor
+func (p *Plot) MouseOut() {
+	p.tracing = false
+	p.Refresh()
+}
+
+// CreateRenderer is a private method to Fyne which links this widget to its renderer
+func (p *Plot) CreateRenderer() fyne.WidgetRenderer {
+	r := &plotRenderer{plot: p, background: canvas.NewRectangle(theme.BackgroundColor())}
+	r.Refresh()
+	return r
+}
+
+type plotRenderer struct {
+	plot       *Plot
+	background *canvas.Rectangle
+	objects    []fyne.CanvasObject
+}
+
+func (r *plotRenderer) Destroy() {
+}
+
+func (r *plotRenderer) Layout(size fyne.Size) {
+	r.Refresh()
+}
+
+func (r *plotRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(320, 240)
+}
+
+func (r *plotRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+// Refresh draws everything again for the current view
+func (r *plotRenderer) Refresh() {
+	p := r.plot
+	size := p.Size()
+	r.background.FillColor = theme.BackgroundColor()
+	r.background.Resize(size)
+	r.objects = []fyne.CanvasObject{r.background}
+	if size.Width <= 0 || size.Height <= 0 || p.MaxX <= p.MinX || p.MaxY <= p.MinY {
+		canvas.Refresh(p)
+		return
+	}
+
+	r.grid(size)
+	for i, c := range p.curves {
+		r.curve(c, Colors[i%len(Colors)], size)
+	}
+	for _, m := range p.marks {
+		dot := canvas.NewCircle(theme.ForegroundColor())
+		pos := p.toScreen(m.X, m.Y)
+		dot.Move(pos.Subtract(fyne.NewPos(4, 4)))
+		dot.Resize(fyne.NewSize(8, 8))
+		r.objects = append(r.objects, dot)
+	}
+	if p.tracing && len(p.curves) > 0 {
+		r.trace(size)
+	}
+	canvas.Refresh(p)
+}
+
+// grid draws the grid lines, the axes and their labels
+func (r *plotRenderer) grid(size fyne.Size) {
+	p := r.plot
+	gridColor := theme.DisabledColor()
+	axisColor := theme.ForegroundColor()
+
+	stepX := niceStep(p.MaxX - p.MinX)
+	for x := math.Ceil(p.MinX/stepX) * stepX; x <= p.MaxX; x += stepX {
+		pos := p.toScreen(x, 0)
+		r.line(fyne.NewPos(pos.X, 0), fyne.NewPos(pos.X, size.Height), gridColor, 0.5)
+		r.label(label(x, stepX), fyne.NewPos(pos.X+2, clamp(p.toScreen(0, 0).Y+2, 0, size.Height-16)))
+	}
+	stepY := niceStep(p.MaxY - p.MinY)
+	for y := math.Ceil(p.MinY/stepY) * stepY; y <= p.MaxY; y += stepY {
+		pos := p.toScreen(0, y)
+		r.line(fyne.NewPos(0, pos.Y), fyne.NewPos(size.Width, pos.Y), gridColor, 0.5)
+		if math.Abs(y) > stepY/2 {
+			r.label(label(y, stepY), fyne.NewPos(clamp(p.toScreen(0, 0).X+2, 0, size.Width-40), pos.Y+2))
+		}
+	}
+
+	origin := p.toScreen(0, 0)
+	if origin.X >= 0 && origin.X <= size.Width {
+		r.line(fyne.NewPos(origin.X, 0), fyne.NewPos(origin.X, size.Height), axisColor, 1)
+	}
+	if origin.Y >= 0 && origin.Y <= size.Height {
+		r.line(fyne.NewPos(0, origin.Y), fyne.NewPos(size.Width, origin.Y), axisColor, 1)
+	}
+}
+
+// curve draws f with one segment per two pixels, breaking the line where f
+// is undefined or jumps across the view like tan at its poles
+func (r *plotRenderer) curve(c Curve, col color.Color, size fyne.Size) {
+	p := r.plot
+	var last fyne.Position
+	var lastY float64
+	have := false
+	height := p.MaxY - p.MinY
+	for px := float32(0); px <= size.Width; px += 2 {
+		x, _ := p.toPlot(fyne.NewPos(px, 0))
+		y, ok := c.F(x)
+		if !ok || math.IsNaN(y) || math.IsInf(y, 0) {
+			have = false
+			continue
+		}
+		pos := p.toScreen(x, y)
+		if have && math.Abs(y-lastY) < 2*height && visible(last, pos, size) {
+			r.line(last, pos, col, 2)
+		}
+		last, lastY, have = pos, y, true
+	}
+}
+
+// trace draws the cursor on the first curve with its coordinates
+func (r *plotRenderer) trace(size fyne.Size) {
+	p := r.plot
+	x, _ := p.toPlot(fyne.NewPos(p.traceX, 0))
+	r.line(fyne.NewPos(p.traceX, 0), fyne.NewPos(p.traceX, size.Height), theme.PrimaryColor(), 1)
+	y, ok := p.curves[0].F(x)
+	text := "x = " + label(x, (p.MaxX-p.MinX)/1000)
+	if ok && !math.IsNaN(y) && !math.IsInf(y, 0) {
+		text += ", y = " + label(y, (p.MaxY-p.MinY)/1000)
+		pos := p.toScreen(x, y)
+		dot := canvas.NewCircle(theme.PrimaryColor())
+		dot.Move(pos.Subtract(fyne.NewPos(4, 4)))
+		dot.Resize(fyne.NewSize(8, 8))
+		r.objects = append(r.objects, dot)
+	}
+	t := canvas.NewText(text, theme.ForegroundColor())
+	t.TextStyle = fyne.TextStyle{Monospace: true}
+	min := t.MinSize()
+	t.Move(fyne.NewPos(clamp(p.traceX+6, 0, size.Width-min.Width), 4))
+	t.Resize(min)
+	r.objects = append(r.objects, t)
+}
+
+func (r *plotRenderer) line(from, to fyne.Position, col color.Color, width float32) {
+	l := canvas.NewLine(col)
+	l.StrokeWidth = width
+	l.Position1, l.Position2 = from, to
+	r.objects = append(r.objects, l)
+}
+
+func (r *plotRenderer) label(text string, pos fyne.Position) {
+	t := canvas.NewText(text, theme.ForegroundColor())
+	t.TextSize = theme.TextSize() * 0.8
+	t.Move(pos)
+	t.Resize(t.MinSize())
+	r.objects = append(r.objects, t)
+}
+
+// niceStep returns a grid step of 1, 2 or 5 times a power of ten giving
+// about eight lines over span
+func niceStep(span float64) float64 {
+	raw := span / 8
+	power := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch fraction := raw / power; {
+	case fraction < 1.5:
+		return power
+	case fraction < 3.5:
+		return 2 * power
+	case fraction < 7.5:
+		return 5 * power
+	}
+	return 10 * power
+}
+
+// label prints v with as many decimals as the step needs
+func label(v, step float64) string {
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	if math.Abs(v) < step/2 {
+		v = 0
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// visible reports whether a segment from a to b may cross the widget
+func visible(a, b fyne.Position, size fyne.Size) bool {
+	return !(a.Y < 0 && b.Y < 0) && !(a.Y > size.Height && b.Y > size.Height)
+}
+
+func clamp(v, min, max float32) float32 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package plot
+
+import (
+	"math"
+	"sort"
+)
+
+// Point is a point of the plane
+type Point struct {
+	X, Y float64
+}
+
+// Span is where a function is zero or two curves meet, a single point or the
+// stretch from From to To where they stay so
+type Span struct {
+	From, To Point
+}
+
+// Single reports whether s is a single point
+func (s Span) Single() bool {
+	return s.From == s.To
+}
+
+// samples is how many steps Roots looks for sign changes and touches in
+const samples = 2000
+
+// Roots returns where in [a, b] f crosses or touches zero, a stretch where f
+// stays at zero is one span. Sign changes across a pole, like tan at pi/2,
+// are not roots and are left out
+func Roots(f Func, a, b float64) []Span {
+	roots := make([]Span, 0)
+	root := func(x float64) {
+		roots = append(roots, Span{Point{x, 0}, Point{x, 0}})
+	}
+	step := (b - a) / samples
+	xs := make([]float64, samples+1)
+	ys := make([]float64, samples+1)
+	oks := make([]bool, samples+1)
+	for i := range xs {
+		xs[i] = a + float64(i)*step
+		ys[i], oks[i] = f(xs[i])
+	}
+	for i := 0; i <= samples; i++ {
+		if !oks[i] {
+			continue
+		}
+		if ys[i] == 0 {
+			// Samples in a row at zero are one stretch, like all of y = 0
+			j := i
+			for j < samples && oks[j+1] && ys[j+1] == 0 {
+				j++
+			}
+			roots = append(roots, Span{Point{xs[i], 0}, Point{xs[j], 0}})
+			i = j
+			continue
+		}
+		switch {
+		case i == samples || !oks[i+1]:
+		case ys[i]*ys[i+1] < 0:
+			if r, ok := bisect(f, xs[i], xs[i+1], ys[i], ys[i+1]); ok {
+				root(r)
+			}
+		case i > 0 && oks[i-1] && touches(ys[i-1], ys[i], ys[i+1]):
+			if r, ok := minimize(f, xs[i-1], xs[i+1], ys[i-1], ys[i+1]); ok {
+				root(r)
+			}
+		}
+	}
+	return dedupe(roots, step)
+}
+
+// Intersections returns where in [a, b] f and g meet, a stretch where they
+// are the same is one span
+func Intersections(f, g Func, a, b float64) []Span {
+	diff := func(x float64) (float64, bool) {
+		fy, ok := f(x)
+		if !ok {
+			return 0, false
+		}
+		gy, ok := g(x)
+		return fy - gy, ok
+	}
+	spans := make([]Span, 0)
+	for _, r := range Roots(diff, a, b) {
+		from, ok := f(r.From.X)
+		if !ok {
+			continue
+		}
+		to, ok := f(r.To.X)
+		if !ok {
+			continue
+		}
+		spans = append(spans, Span{Point{r.From.X, from}, Point{r.To.X, to}})
+	}
+	return spans
+}
+
+// bisect narrows a sign change of f between a and b down to a root
+func bisect(f Func, a, b, ya, yb float64) (float64, bool) {
+	// A root shrinks the values as the interval narrows, a pole grows them.
+	// bound is the smaller sample or the first midpoint, whichever is larger
+	bound := math.Min(math.Abs(ya), math.Abs(yb))
+	for i := 0; i < 100 && b-a > 1e-15*math.Max(1, math.Abs(a)); i++ {
+		m := (a + b) / 2
+		ym, ok := f(m)
+		if !ok {
+			return 0, false
+		}
+		if ym == 0 {
+			return m, true
+		}
+		if i == 0 {
+			bound = math.Max(bound, math.Abs(ym))
+		}
+		if ya*ym < 0 {
+			b = m
+		} else {
+			a, ya = m, ym
+		}
+	}
+	m := (a + b) / 2
+	y, ok := f(m)
+	return m, ok && math.Abs(y) <= bound
+}
+
+// touches reports whether the middle of three samples of the same sign is
+// closest to zero, f may touch zero around it
+func touches(y0, y1, y2 float64) bool {
+	return y0*y1 > 0 && y1*y2 > 0 && math.Abs(y1) <= math.Abs(y0) && math.Abs(y1) <= math.Abs(y2)
+}
+
+// minimize looks for the x between a and b where |f| is least, it is a root
+// when |f| there is next to nothing beside the samples ya and yb
+func minimize(f Func, a, b, ya, yb float64) (float64, bool) {
+	abs := func(x float64) float64 {
+		y, ok := f(x)
+		if !ok {
+			return math.Inf(1)
+		}
+		return math.Abs(y)
+	}
+	// Golden section search
+	r := (math.Sqrt(5) - 1) / 2
+	c, d := b-r*(b-a), a+r*(b-a)
+	fc, fd := abs(c), abs(d)
+	for i := 0; i < 200 && b-a > 1e-15*math.Max(1, math.Abs(a)); i++ {
+		if fc <= fd {
+			b, d, fd = d, c, fc
+			c = b - r*(b-a)
+			fc = abs(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + r*(b-a)
+			fd = abs(d)
+		}
+	}
+	m := (a + b) / 2
+	return m, abs(m) <= 1e-9*math.Max(math.Abs(ya), math.Abs(yb))
+}
+
+// dedupe drops roots closer than step to the one before
+func dedupe(roots []Span, step float64) []Span {
+	sort.Slice(roots, func(i, j int) bool { return roots[i].From.X < roots[j].From.X })
+	unique := make([]Span, 0, len(roots))
+	for _, r := range roots {
+		if len(unique) == 0 || r.From.X-unique[len(unique)-1].To.X > step {
+			unique = append(unique, r)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,64 @@
+package plot
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether got has one span per want, each a point close to it
+// or a stretch from close to its From to close to its To
+func near(got []Span, want [][2]float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i, s := range got {
+		if math.Abs(s.From.X-want[i][0]) > 1e-6 || math.Abs(s.To.X-want[i][1]) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoots(t *testing.T) {
+	tests := []struct {
+		name string
+		f    Func
+		a, b float64
+		want [][2]float64
+	}{
+		{"x^2 - 4", func(x float64) (float64, bool) { return x*x - 4, true }, -5, 5, [][2]float64{{-2, -2}, {2, 2}}},
+		{"x^2 touching", func(x float64) (float64, bool) { return x * x, true }, -3.3, 4.1, [][2]float64{{0, 0}}},
+		{"(x - 1)^2 + 1 above", func(x float64) (float64, bool) { return (x-1)*(x-1) + 1, true }, -5, 5, nil},
+		{"tan past its pole", func(x float64) (float64, bool) { return math.Tan(x), true }, 0.5, 4, [][2]float64{{math.Pi, math.Pi}}},
+		{"1/x", func(x float64) (float64, bool) { return 1 / x, x != 0 }, -1, 1, nil},
+		{"pole next to a sample", func(x float64) (float64, bool) { return 1 / (x - 0.3), true }, -1, 1, nil},
+		{"steep", func(x float64) (float64, bool) { return 1e12 * (x - 0.3), true }, -1, 1, [][2]float64{{0.3, 0.3}}},
+		{"y = 0", func(x float64) (float64, bool) { return 0, true }, -10, 10, [][2]float64{{-10, 10}}},
+		{"0 up to 0 then x", func(x float64) (float64, bool) { return math.Max(0, x), true }, -1, 1, [][2]float64{{-1, 0}}},
+		{"sqrt outside its domain", func(x float64) (float64, bool) { return math.Sqrt(x) - 1, x >= 0 }, -4, 4, [][2]float64{{1, 1}}},
+	}
+	for _, test := range tests {
+		if got := Roots(test.f, test.a, test.b); !near(got, test.want) {
+			t.Errorf("roots of %s = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIntersections(t *testing.T) {
+	line := func(x float64) (float64, bool) { return x, true }
+	square := func(x float64) (float64, bool) { return x * x, true }
+	got := Intersections(line, square, -1, 2)
+	if !near(got, [][2]float64{{0, 0}, {1, 1}}) || math.Abs(got[1].From.Y-1) > 1e-6 {
+		t.Errorf("x and x^2 meet at %v, want (0, 0) and (1, 1)", got)
+	}
+
+	got = Intersections(square, square, -2, 2)
+	if !near(got, [][2]float64{{-2, 2}}) || got[0].Single() {
+		t.Errorf("x^2 and x^2 meet at %v, want all of -2 to 2", got)
+	}
+
+	shifted := func(x float64) (float64, bool) { return x*x + 1, true }
+	if got = Intersections(square, shifted, -5, 5); len(got) != 0 {
+		t.Errorf("x^2 and x^2 + 1 meet at %v, want nowhere", got)
+	}
+}
@@ -0,0 +1,162 @@
+package calculator
+
+import (
+	"strconv"
+
+	"calculator/expr"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// baseNames labels the bases of programmer mode
+var baseNames = map[int]string{16: "HEX", 10: "DEC", 8: "OCT", 2: "BIN"}
+
+// programmerPanel shows the input of programmer mode in every base, with
+// keys for hex digits and bitwise operators and a button per bit
+type programmerPanel struct {
+	prog    *expr.Programmer
+	input   *widget.Entry
+	value   uint64
+	bases   map[int]*widget.Label
+	hexKeys []*widget.Button
+	bits    *fyne.Container
+
+	content *fyne.Container
+}
+
+func newProgrammerPanel(prog *expr.Programmer, input *widget.Entry, insert func(string)) *programmerPanel {
+	p := &programmerPanel{prog: prog, input: input, bases: make(map[int]*widget.Label)}
+
+	// Base and Word Size
+	names := make([]string, len(expr.Bases))
+	for i, base := range expr.Bases {
+		names[i] = baseNames[base]
+	}
+	baseRadio := widget.NewRadioGroup(names, nil)
+	baseRadio.Horizontal = true
+	baseRadio.Required = true
+	baseRadio.SetSelected(baseNames[prog.Base])
+	baseRadio.OnChanged = func(selected string) {
+		for base, name := range baseNames {
+			if name == selected {
+				p.setBase(base)
+			}
+		}
+	}
+	sizes := make([]string, len(expr.WordSizes))
+	for i, size := range expr.WordSizes {
+		sizes[i] = strconv.Itoa(size) + " bit"
+	}
+	sizeSelect := widget.NewSelect(sizes, nil)
+	sizeSelect.SetSelected(strconv.Itoa(prog.Bits) + " bit")
+	sizeSelect.OnChanged = func(_ string) {
+		prog.Bits = expr.WordSizes[sizeSelect.SelectedIndex()]
+		p.reevaluate()
+	}
+	signed := widget.NewCheck("Signed", nil)
+	signed.SetChecked(prog.Signed)
+	signed.OnChanged = func(checked bool) {
+		prog.Signed = checked
+		p.reevaluate()
+	}
+
+	// Value in every Base
+	values := container.New(layout.NewFormLayout())
+	for _, base := range expr.Bases {
+		p.bases[base] = widget.NewLabel("0")
+		p.bases[base].Wrapping = fyne.TextWrapBreak
+		values.Add(widget.NewLabel(baseNames[base]))
+		values.Add(p.bases[base])
+	}
+
+	// Keys
+	keys := container.New(ui.GridLayout("C", 6))
+	for _, digit := range "ABCDEF" {
+		d := string(digit)
+		key := widget.NewButton(d, func() { insert(d) })
+		p.hexKeys = append(p.hexKeys, key)
+		keys.Add(key)
+	}
+	for _, op := range []struct{ label, text string }{
+		{"AND", " & "}, {"OR", " | "}, {"XOR", " xor "}, {"NOT", "~"}, {"<<", " << "}, {">>", " >> "},
+	} {
+		text := op.text
+		keys.Add(widget.NewButton(op.label, func() { insert(text) }))
+	}
+
+	p.bits = container.New(ui.GridLayout("C", 16))
+	p.content = container.New(
+		ui.BoxLayout("V"),
+		container.NewHBox(baseRadio, sizeSelect, signed),
+		values,
+		p.bits,
+		keys,
+	)
+	p.setBase(prog.Base)
+	p.show(0)
+	return p
+}
+
+// setBase converts the input to base and enables the keys of its digits
+func (p *programmerPanel) setBase(base int) {
+	v, err := p.prog.Evaluate(p.input.Text)
+	p.prog.Base = base
+	if err == nil && p.input.Text != "" {
+		p.input.SetText(p.prog.Input(v))
+	}
+	for _, key := range p.hexKeys {
+		if base == 16 {
+			key.Enable()
+		} else {
+			key.Disable()
+		}
+	}
+}
+
+// reevaluate shows the input again after the word size changed
+func (p *programmerPanel) reevaluate() {
+	p.update(p.input.Text)
+}
+
+// update shows the value of text, an invalid text keeps the last value
+func (p *programmerPanel) update(text string) {
+	if text == "" {
+		p.show(0)
+		return
+	}
+	if v, err := p.prog.Evaluate(text); err == nil {
+		p.show(v)
+	}
+}
+
+// show displays v in every base and on the bit buttons
+func (p *programmerPanel) show(v uint64) {
+	p.value = p.prog.Mask(v)
+	for base, label := range p.bases {
+		label.SetText(p.prog.Format(p.value, base))
+	}
+
+	if len(p.bits.Objects) != p.prog.Bits {
+		p.bits.Objects = nil
+		for i := p.prog.Bits - 1; i >= 0; i-- {
+			bit := i
+			p.bits.Add(widget.NewButton("0", func() {
+				p.input.SetText(p.prog.Input(expr.ToggleBit(p.value, bit)))
+			}))
+		}
+		p.bits.Refresh()
+	}
+	for i, object := range p.bits.Objects {
+		button := object.(*widget.Button)
+		button.Importance = widget.MediumImportance
+		button.Text = "0"
+		if expr.Bit(p.value, p.prog.Bits-1-i) {
+			button.Importance = widget.HighImportance
+			button.Text = "1"
+		}
+		button.Refresh()
+	}
+}
@@ -0,0 +1,130 @@
+package calculator
+
+import (
+	"calculator/expr"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// memory is the M register of the scientific keypad
+type memory struct {
+	value float64
+	used  bool
+	label *widget.Label
+}
+
+func newMemory(value float64, used bool) *memory {
+	m := &memory{label: widget.NewLabel("")}
+	m.set(value, used)
+	return m
+}
+
+func (m *memory) set(value float64, used bool) {
+	m.value, m.used = value, used
+	if used {
+		m.label.SetText("M = " + expr.Format(value))
+	} else {
+		m.label.SetText("")
+	}
+}
+
+// numberModes are the labels of the real, complex and polar complex modes
+var numberModes = []string{"Real", "a+bi", "r·eⁱᶿ"}
+
+// scientificKeypad returns the function and memory keys shown above the
+// basic keypad in scientific mode. current evaluates the input for M+ and M-,
+// changed is called when the angle unit or the kind of numbers change
+func scientificKeypad(a fyne.App, e *engine, m *memory, insert func(string), current func() (float64, bool), changed func()) fyne.CanvasObject {
+	env := e.Env
+	key := func(label, text string) *widget.Button {
+		return widget.NewButton(label, func() { insert(text) })
+	}
+	angle := widget.NewButton(expr.Angles[env.Angle], nil)
+	angle.OnTapped = func() {
+		env.Angle = (env.Angle + 1) % expr.Angle(len(expr.Angles))
+		angle.SetText(expr.Angles[env.Angle])
+		changed()
+	}
+	numberMode := func() int {
+		switch {
+		case e.Complex && e.Polar:
+			return 2
+		case e.Complex:
+			return 1
+		}
+		return 0
+	}
+	numbers := widget.NewButton(numberModes[numberMode()], nil)
+	numbers.OnTapped = func() {
+		mode := (numberMode() + 1) % len(numberModes)
+		e.Complex, e.Polar = mode > 0, mode == 2
+		e.save(a)
+		numbers.SetText(numberModes[mode])
+		changed()
+	}
+	memoryAdd := func(sign float64) func() {
+		return func() {
+			if v, ok := current(); ok {
+				m.set(m.value+sign*v, true)
+			}
+		}
+	}
+
+	return container.New(
+		ui.GridLayout("C", 6),
+		angle,
+		widget.NewButton("MC", func() { m.set(0, false) }),
+		widget.NewButton("MR", func() {
+			if m.used {
+				insert(expr.Format(m.value))
+			}
+		}),
+		widget.NewButton("M+", memoryAdd(1)),
+		widget.NewButton("M-", memoryAdd(-1)),
+		key("Ans", "Ans"),
+
+		key("sin", "sin("),
+		key("cos", "cos("),
+		key("tan", "tan("),
+		key("sinh", "sinh("),
+		key("cosh", "cosh("),
+		key("tanh", "tanh("),
+
+		key("asin", "asin("),
+		key("acos", "acos("),
+		key("atan", "atan("),
+		key("asinh", "asinh("),
+		key("acosh", "acosh("),
+		key("atanh", "atanh("),
+
+		key("x²", "^2"),
+		key("x³", "^3"),
+		key("xʸ", "^"),
+		key("√", "sqrt("),
+		key("∛", "cbrt("),
+		key("ʸ√x", "root("),
+
+		key("log", "log("),
+		key("ln", "ln("),
+		key("log₂", "log2("),
+		key("10ˣ", "10^"),
+		key("eˣ", "exp("),
+		key("1/x", "^-1"),
+
+		key("π", "pi"),
+		key("e", "e"),
+		key("n!", "!"),
+		key("%", "%"),
+		key("|x|", "abs("),
+		key(",", ","),
+
+		numbers,
+		key("i", "i"),
+		key("arg", "arg("),
+		key("conj", "conj("),
+		key("re", "re("),
+		key("im", "im("),
+	)
+}
@@ -0,0 +1,77 @@
+package calculator
+
+import (
+	"strconv"
+
+	"calculator/expr"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// precisions offered in the settings, in bits
+var precisions = []string{"64", "128", "256", "512", "1024"}
+
+// decimalPlaces offered in the settings
+var decimalPlaces = []string{"0", "2", "4", "6", "8", "10", "15", "20", "30", "50"}
+
+// showSettings opens the arithmetic and keyboard settings of the calculator,
+// changed is called once they are saved
+func showSettings(a fyne.App, w fyne.Window, e *engine, k *keyboard, changed func()) {
+	exact := widget.NewCheck("Exact arithmetic", nil)
+	exact.SetChecked(e.Exact)
+
+	precision := widget.NewSelect(precisions, nil)
+	precision.SetSelected(strconv.Itoa(int(e.Rational.Prec)))
+	places := widget.NewSelect(decimalPlaces, nil)
+	places.SetSelected(strconv.Itoa(e.Rational.Places))
+
+	roundings := make([]string, len(expr.Roundings))
+	for mode, name := range expr.Roundings {
+		roundings[mode] = name
+	}
+	rounding := widget.NewSelect(roundings, nil)
+	rounding.SetSelected(expr.Roundings[e.Rational.Rounding])
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("", exact),
+		widget.NewFormItem("Precision (bits)", precision),
+		widget.NewFormItem("Decimal places", places),
+		widget.NewFormItem("Rounding", rounding),
+	}
+	keyEntries := make(map[string]*widget.Entry, len(keyActions))
+	for _, action := range keyActions {
+		entry := widget.NewEntry()
+		entry.SetText(k.keys.keys(action.Name))
+		entry.SetPlaceHolder(action.Keys)
+		entry.Validator = func(s string) error {
+			_, err := parseKeys(s)
+			return err
+		}
+		keyEntries[action.Name] = entry
+		items = append(items, widget.NewFormItem(action.Label, entry))
+	}
+	dialog.ShowForm("Calculator Settings", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		e.Exact = exact.Checked
+		if bits, err := strconv.Atoi(precision.Selected); err == nil {
+			e.Rational.Prec = uint(bits)
+		}
+		if n, err := strconv.Atoi(places.Selected); err == nil {
+			e.Rational.Places = n
+		}
+		e.Rational.Rounding = expr.Rounding(rounding.SelectedIndex())
+		e.save(a)
+		keys := make(map[string]string, len(keyEntries))
+		for action, entry := range keyEntries {
+			keys[action] = entry.Text
+		}
+		if err := k.setKeys(a, keys); err != nil {
+			ui.ShowError(err, w)
+		}
+		changed()
+	}, w)
+}
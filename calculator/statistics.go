@@ -0,0 +1,149 @@
+package calculator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"calculator/expr"
+	"calculator/stats"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+)
+
+// statisticsPanel returns statistics mode, a dataset typed, pasted or
+// imported from a CSV file with its summary. With two columns or more a line
+// is fitted through the first two. Tapping a result inserts it into the input
+func statisticsPanel(w fyne.Window, insert func(string)) fyne.CanvasObject {
+	data := widget.NewMultiLineEntry()
+	data.SetPlaceHolder("One value per line, or x, y pairs")
+	message := widget.NewLabel("")
+	results := container.New(ui.GridLayout("C", 4))
+	column := widget.NewSelect(nil, nil)
+	column.Hide()
+
+	result := func(name string, v float64) {
+		text := expr.Format(v)
+		results.Add(widget.NewLabel(name))
+		results.Add(widget.NewButton(text, func() { insert(text) }))
+	}
+	update := func() {
+		results.Objects = nil
+		defer results.Refresh()
+		rows, err := stats.Parse(data.Text)
+		if err == nil && len(rows) == 0 {
+			err = stats.ErrEmpty
+		}
+		if err != nil {
+			column.Hide()
+			message.SetText("ERROR : " + err.Error())
+			if strings.TrimSpace(data.Text) == "" {
+				message.SetText("")
+			}
+			return
+		}
+
+		columns := make([]string, len(rows[0]))
+		for i := range columns {
+			columns[i] = "Column " + strconv.Itoa(i+1)
+		}
+		if len(columns) > 1 {
+			column.Show()
+		} else {
+			column.Hide()
+		}
+		if len(column.Options) != len(columns) {
+			column.Options = columns
+			column.Selected = columns[0]
+			column.Refresh()
+		}
+		selected := column.SelectedIndex()
+		if selected < 0 {
+			selected = 0
+		}
+
+		s, _ := stats.Describe(stats.Column(rows, selected))
+		message.SetText(fmt.Sprintf("%d values", s.Count))
+		result("Sum", s.Sum)
+		result("Mean", s.Mean)
+		result("Min", s.Min)
+		result("Max", s.Max)
+		result("Median", s.Median)
+		result("Q1", s.Q1)
+		result("Q3", s.Q3)
+		result("IQR", s.Q3-s.Q1)
+		result("Variance (s²)", s.SampleVariance)
+		result("Std dev (s)", s.SampleStdDev)
+		result("Variance (σ²)", s.PopulationVariance)
+		result("Std dev (σ)", s.PopulationStdDev)
+		for _, m := range s.Modes {
+			result("Mode", m)
+		}
+		if len(s.Modes) == 0 {
+			results.Add(widget.NewLabel("Mode"))
+			results.Add(widget.NewLabel("none"))
+		}
+
+		if len(columns) > 1 {
+			line, err := stats.Regression(stats.Column(rows, 0), stats.Column(rows, 1))
+			if err != nil {
+				message.SetText(message.Text + ", no regression: " + err.Error())
+				return
+			}
+			message.SetText(fmt.Sprintf("%s, y = %s + %s x fitted to columns 1 and 2", message.Text, expr.Format(line.Intercept), expr.Format(line.Slope)))
+			result("Slope", line.Slope)
+			result("Intercept", line.Intercept)
+			// r is undefined when every y value is the same
+			if !math.IsNaN(line.R) {
+				result("r", line.R)
+				result("r²", line.R*line.R)
+			}
+		}
+	}
+	data.OnChanged = func(string) { update() }
+	column.OnChanged = func(string) { update() }
+
+	pasteBtn := widget.NewButtonWithIcon("Paste", theme.ContentPasteIcon(), func() {
+		text := w.Clipboard().Content()
+		if data.Text != "" && !strings.HasSuffix(data.Text, "\n") {
+			text = "\n" + text
+		}
+		data.SetText(data.Text + text)
+	})
+	importBtn := widget.NewButtonWithIcon("Import CSV", theme.FolderOpenIcon(), func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			content, err := ioutil.ReadAll(reader)
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			data.SetText(string(content))
+		}, w)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".csv", ".txt"}))
+		open.Show()
+	})
+	clearBtn := widget.NewButtonWithIcon("Clear", theme.CancelIcon(), func() { data.SetText("") })
+
+	return container.New(
+		ui.BoxLayout("V"),
+		container.NewHBox(pasteBtn, importBtn, clearBtn, column),
+		data,
+		message,
+		results,
+	)
+}
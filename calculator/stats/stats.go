@@ -0,0 +1,187 @@
+// Package stats describes datasets for the calculator's statistics mode and
+// fits lines through paired data.
+package stats
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrEmpty is returned for a dataset without values
+var ErrEmpty = errors.New("the dataset is empty")
+
+// Summary describes a dataset. The variances and standard deviations are of
+// a sample, dividing by n - 1, and of a whole population, dividing by n
+type Summary struct {
+	Count              int
+	Sum                float64
+	Min, Max           float64
+	Mean, Median       float64
+	Modes              []float64
+	Q1, Q3             float64
+	SampleVariance     float64
+	SampleStdDev       float64
+	PopulationVariance float64
+	PopulationStdDev   float64
+}
+
+// Describe summarizes data, which is not changed
+func Describe(data []float64) (Summary, error) {
+	if len(data) == 0 {
+		return Summary{}, ErrEmpty
+	}
+	sorted := append([]float64(nil), data...)
+	sort.Float64s(sorted)
+
+	s := Summary{Count: len(data), Min: sorted[0], Max: sorted[len(sorted)-1]}
+	for _, v := range data {
+		s.Sum += v
+	}
+	s.Mean = s.Sum / float64(s.Count)
+	s.Median = Quantile(sorted, 0.5)
+	s.Q1, s.Q3 = Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+	s.Modes = modes(sorted)
+
+	squares := 0.0
+	for _, v := range data {
+		squares += (v - s.Mean) * (v - s.Mean)
+	}
+	s.PopulationVariance = squares / float64(s.Count)
+	s.PopulationStdDev = math.Sqrt(s.PopulationVariance)
+	if s.Count > 1 {
+		s.SampleVariance = squares / float64(s.Count-1)
+		s.SampleStdDev = math.Sqrt(s.SampleVariance)
+	}
+	return s, nil
+}
+
+// Quantile returns the q quantile, 0 to 1, of sorted data interpolating
+// between the closest values, like QUARTILE.INC of spreadsheets
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	pos := q * float64(len(sorted)-1)
+	i := int(math.Floor(pos))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// modes returns the most frequent values of sorted data, or none if every
+// value is there once
+func modes(sorted []float64) []float64 {
+	var found []float64
+	best := 1
+	for i := 0; i < len(sorted); {
+		j := i
+		for j < len(sorted) && sorted[j] == sorted[i] {
+			j++
+		}
+		switch n := j - i; {
+		case n > best:
+			best, found = n, []float64{sorted[i]}
+		case n == best && n > 1:
+			found = append(found, sorted[i])
+		}
+		i = j
+	}
+	return found
+}
+
+// Line is the least squares line y = Intercept + Slope x, R is the
+// correlation coefficient of the data it was fitted to, NaN when every y
+// value is the same
+type Line struct {
+	Slope, Intercept, R float64
+}
+
+// Regression fits a line through the points (x[i], y[i])
+func Regression(x, y []float64) (Line, error) {
+	if len(x) != len(y) {
+		return Line{}, fmt.Errorf("%d x values and %d y values cannot be paired", len(x), len(y))
+	}
+	if len(x) < 2 {
+		return Line{}, errors.New("a line needs at least two points")
+	}
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX, meanY = meanX/n, meanY/n
+	var sxx, syy, sxy float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	}
+	if sxx == 0 {
+		return Line{}, errors.New("every x value is the same, the line would be vertical")
+	}
+	l := Line{Slope: sxy / sxx}
+	l.Intercept = meanY - l.Slope*meanX
+	if syy == 0 {
+		l.R = math.NaN()
+	} else {
+		l.R = sxy / math.Sqrt(sxx*syy)
+	}
+	return l, nil
+}
+
+// Parse reads a column of numbers or rows of numbers separated by commas,
+// semicolons, tabs or spaces, as pasted from a spreadsheet or a CSV file. A
+// first row that is not numeric is taken as a header and skipped. Every row
+// must have as many values as the first
+func Parse(text string) ([][]float64, error) {
+	var rows [][]float64
+	header := true
+	for n, line := range strings.Split(text, "\n") {
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == '\t' || r == ' ' || r == '\r'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]float64, len(fields))
+		var err error
+		for i, f := range fields {
+			if row[i], err = strconv.ParseFloat(strings.Trim(f, `"`), 64); err != nil {
+				break
+			}
+			// ParseFloat reads NaN and Inf, they would spoil every figure
+			if math.IsNaN(row[i]) || math.IsInf(row[i], 0) {
+				return nil, fmt.Errorf("line %d: %q is not a finite number", n+1, strings.TrimSpace(f))
+			}
+		}
+		if err != nil && header {
+			header = false
+			continue
+		}
+		header = false
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q is not a number", n+1, strings.TrimSpace(line))
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("line %d has %d values, the first has %d", n+1, len(row), len(rows[0]))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Column returns column i of rows
+func Column(rows [][]float64, i int) []float64 {
+	col := make([]float64, len(rows))
+	for j, row := range rows {
+		col[j] = row[i]
+	}
+	return col
+}
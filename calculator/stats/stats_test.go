@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	s, err := Describe([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"count", float64(s.Count), 8},
+		{"sum", s.Sum, 40},
+		{"min", s.Min, 2},
+		{"max", s.Max, 9},
+		{"mean", s.Mean, 5},
+		{"median", s.Median, 4.5},
+		{"Q1", s.Q1, 4},
+		{"Q3", s.Q3, 5.5},
+		{"population variance", s.PopulationVariance, 4},
+		{"population standard deviation", s.PopulationStdDev, 2},
+		{"sample variance", s.SampleVariance, 32.0 / 7},
+	}
+	for _, test := range tests {
+		if math.Abs(test.got-test.want) > 1e-12 {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if want := []float64{4}; !reflect.DeepEqual(s.Modes, want) {
+		t.Errorf("modes = %v, want %v", s.Modes, want)
+	}
+	if _, err := Describe(nil); err != ErrEmpty {
+		t.Errorf("empty dataset: %v, want ErrEmpty", err)
+	}
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		data, want []float64
+	}{
+		{[]float64{1, 2, 3}, nil},
+		{[]float64{1, 1, 2, 2, 3}, []float64{1, 2}},
+		{[]float64{5, 5, 5}, []float64{5}},
+	}
+	for _, test := range tests {
+		if got := modes(test.data); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("modes of %v = %v, want %v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4}
+	tests := []struct {
+		q, want float64
+	}{
+		{0, 1},
+		{0.25, 1.75},
+		{0.5, 2.5},
+		{1, 4},
+	}
+	for _, test := range tests {
+		if got := Quantile(sorted, test.q); got != test.want {
+			t.Errorf("quantile %v = %v, want %v", test.q, got, test.want)
+		}
+	}
+	if got := Quantile(nil, 0.5); !math.IsNaN(got) {
+		t.Errorf("quantile of nothing = %v, want NaN", got)
+	}
+}
+
+func TestRegression(t *testing.T) {
+	l, err := Regression([]float64{1, 2, 3, 4}, []float64{3, 5, 7, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Slope != 2 || l.Intercept != 1 || math.Abs(l.R-1) > 1e-12 {
+		t.Errorf("line = %+v, want slope 2, intercept 1 and r 1", l)
+	}
+
+	l, err = Regression([]float64{1, 2, 3}, []float64{4, 4, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Slope != 0 || l.Intercept != 4 || !math.IsNaN(l.R) {
+		t.Errorf("flat line = %+v, want slope 0, intercept 4 and r NaN", l)
+	}
+
+	errs := []struct {
+		x, y []float64
+	}{
+		{[]float64{1, 2}, []float64{1}},
+		{[]float64{1}, []float64{1}},
+		{[]float64{2, 2}, []float64{1, 3}},
+	}
+	for _, test := range errs {
+		if _, err := Regression(test.x, test.y); err == nil {
+			t.Errorf("regression of %v and %v: no error", test.x, test.y)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [][]float64
+	}{
+		{"1\n2\n\n3", [][]float64{{1}, {2}, {3}}},
+		{"x,y\r\n1,2\r\n3,4\r\n", [][]float64{{1, 2}, {3, 4}}},
+		{"1;2\n3\t4", [][]float64{{1, 2}, {3, 4}}},
+		{`"1.5" "-2e3"`, [][]float64{{1.5, -2000}}},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q = %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1\ntwo", `line 2: "two" is not a number`},
+		{"1,2\n3", "line 2 has 1 values, the first has 2"},
+		{"1\nNaN\n3", `line 2: "NaN" is not a finite number`},
+		{"x\n1\ninf", `line 3: "inf" is not a finite number`},
+		{"1\n-Infinity", `line 2: "-Infinity" is not a finite number`},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.in)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%q: got %v, want %s", test.in, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package units
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Rates is a currency rates table, one unit of Base buys Rates[code] of
+// each currency
+type Rates struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+//go:embed rates.json
+var defaultRates []byte
+
+var rates Rates
+
+func init() {
+	r, err := ParseRates(defaultRates)
+	if err != nil {
+		panic(err)
+	}
+	if err := SetRates(r); err != nil {
+		panic(err)
+	}
+}
+
+// DefaultRates returns the rates shipped with the calculator
+func DefaultRates() Rates {
+	r, _ := ParseRates(defaultRates)
+	return r
+}
+
+// ParseRates reads a rates table in JSON
+func ParseRates(data []byte) (Rates, error) {
+	var r Rates
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, err
+	}
+	if r.Base == "" || len(r.Rates) == 0 {
+		return r, fmt.Errorf("rates need a base currency and a rates table")
+	}
+	return r, nil
+}
+
+// LoadRates reads a rates table in JSON from reader
+func LoadRates(reader io.Reader) (Rates, error) {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return Rates{}, err
+	}
+	return ParseRates(data)
+}
+
+// SetRates replaces the currency units with the ones of r. The base
+// dimension of money is kept in US dollars, so r must list USD unless it is
+// its base
+func SetRates(r Rates) error {
+	table := make(map[string]float64, len(r.Rates)+1)
+	for code, rate := range r.Rates {
+		table[strings.ToUpper(code)] = rate
+	}
+	table[strings.ToUpper(r.Base)] = 1
+	usd, ok := table["USD"]
+	if !ok || usd <= 0 {
+		return fmt.Errorf("rates must include USD")
+	}
+
+	list := make([]*Unit, 0, len(table))
+	for code, rate := range table {
+		if rate <= 0 {
+			return fmt.Errorf("rate of %s must be positive", code)
+		}
+		list = append(list, &Unit{Symbol: code, Name: code, Category: "Currency", Factor: usd / rate, Dim: category("Currency")})
+	}
+	setCurrencies(list)
+	mu.Lock()
+	rates = r
+	mu.Unlock()
+	return nil
+}
+
+// CurrentRates returns the rates in use
+func CurrentRates() Rates {
+	mu.RLock()
+	defer mu.RUnlock()
+	return rates
+}
@@ -0,0 +1,347 @@
+package units
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"calculator/expr"
+)
+
+// Quantity is a value in base units with its dimension. Unit is the unit
+// the value is shown in unless a conversion asks for another one, it may be
+// nil. Absolute marks temperatures read with an offset unit, like 20 degC,
+// rather than differences of them. Kelvins work as differences, but a
+// plain kelvin value converts to degC and degF as a reading
+type Quantity struct {
+	Value    float64
+	Dim      Dim
+	Unit     *Unit
+	Absolute bool
+
+	// offset is set while a bare offset unit waits for its number
+	offset *Unit
+	// difference marks temperatures that can only be differences, like
+	// 30 degC - 20 degC, so they convert without the offset
+	difference bool
+}
+
+// Converter evaluates expressions with units, the names that are not units
+// come from Env
+type Converter struct {
+	Env *expr.Env
+}
+
+// Result is an evaluated conversion
+type Result struct {
+	Quantity Quantity
+	// Value is the number shown with Unit
+	Value float64
+	Unit  string
+}
+
+// String prints r like "3.29 mi"
+func (r Result) String() string {
+	if r.Unit == "" {
+		return expr.Format(r.Value)
+	}
+	return expr.Format(r.Value) + " " + r.Unit
+}
+
+// Evaluate reads s, like "5 km + 300 m in mi", and converts its value to
+// the target after "in" or "to" if there is one
+func (c *Converter) Evaluate(s string) (Result, error) {
+	value, target, err := expr.ParseConversion(s)
+	if err != nil {
+		return Result{}, err
+	}
+	q, err := c.Eval(value)
+	if err != nil {
+		return Result{}, err
+	}
+	if target == nil {
+		return Display(q), nil
+	}
+
+	t, err := c.Eval(target)
+	if err != nil {
+		return Result{}, err
+	}
+	name := strings.TrimSpace(string([]rune(s)[target.Pos():target.End()]))
+	if t.Dim != q.Dim {
+		return Result{}, &expr.Error{Pos: target.Pos(), End: target.End(), Msg: fmt.Sprintf("cannot convert %s to %s", q.Dim, t.Dim)}
+	}
+	if t.offset != nil {
+		return Result{Quantity: q, Value: fromBase(q, t.offset), Unit: name}, nil
+	}
+	if t.Value == 0 {
+		return Result{}, &expr.Error{Pos: target.Pos(), End: target.End(), Msg: "cannot convert to a zero unit"}
+	}
+	return Result{Quantity: q, Value: q.Value / t.Value, Unit: name}, nil
+}
+
+// fromBase returns the value of q in unit, with its offset for absolute
+// temperatures and kelvin readings
+func fromBase(q Quantity, unit *Unit) float64 {
+	if q.Absolute || unit.Offset != 0 && !q.difference {
+		v := (q.Value - unit.Offset) / unit.Factor
+		// Drop the rounding error left by subtracting the offset
+		return roundTo(v, math.Max(math.Abs(q.Value), math.Abs(unit.Offset))/unit.Factor)
+	}
+	return q.Value / unit.Factor
+}
+
+// roundTo rounds v to 12 digits of scale, the rounding error of
+// subtracting offsets or temperatures is well below them
+func roundTo(v, scale float64) float64 {
+	if scale == 0 {
+		return v
+	}
+	digits := math.Pow(10, 12-math.Ceil(math.Log10(scale)))
+	return math.Round(v*digits) / digits
+}
+
+// Display shows q in its own unit or else in base units
+func Display(q Quantity) Result {
+	q = settle(q)
+	if q.Dim.None() {
+		return Result{Quantity: q, Value: q.Value}
+	}
+	if q.Unit != nil && q.Unit.Dim == q.Dim {
+		return Result{Quantity: q, Value: fromBase(q, q.Unit), Unit: q.Unit.Symbol}
+	}
+	for _, unit := range builtin {
+		if unit.Dim == q.Dim && unit.Factor == 1 && unit.Offset == 0 {
+			return Result{Quantity: q, Value: q.Value, Unit: unit.Symbol}
+		}
+	}
+	return Result{Quantity: q, Value: q.Value, Unit: q.Dim.symbol()}
+}
+
+func errorAt(n expr.Node, format string, args ...interface{}) error {
+	return &expr.Error{Pos: n.Pos(), End: n.End(), Msg: fmt.Sprintf(format, args...)}
+}
+
+// settle applies a bare offset unit as a difference, like the degC of
+// "3 (degC/s)"
+func settle(q Quantity) Quantity {
+	if q.offset != nil {
+		q.Unit = q.offset
+		q.offset = nil
+		q.difference = true
+	}
+	return q
+}
+
+// Eval evaluates a parsed expression with units
+func (c *Converter) Eval(n expr.Node) (Quantity, error) {
+	switch n := n.(type) {
+	case *expr.Num:
+		v, err := c.Env.Eval(n)
+		return Quantity{Value: v}, err
+	case *expr.Paren:
+		q, err := c.Eval(n.X)
+		return settle(q), err
+	case *expr.Ident:
+		if unit, ok := Find(n.Name); ok {
+			q := Quantity{Value: unit.Factor, Dim: unit.Dim, Unit: unit}
+			if unit.Offset != 0 {
+				q.offset = unit
+			}
+			return q, nil
+		}
+		v, err := c.Env.Eval(n)
+		return Quantity{Value: v}, err
+	case *expr.Unary:
+		q, err := c.Eval(n.X)
+		if err != nil {
+			return q, err
+		}
+		q = settle(q)
+		switch n.Op {
+		case "-":
+			q.Value = -q.Value
+			if q.Absolute {
+				// -5 degC is as far below 0 degC as 5 degC is above
+				q.Value += 2 * q.Unit.Offset
+			}
+		case "!", "~":
+			if !q.Dim.None() {
+				return q, errorAt(n, "%s needs a plain number, not %s", n.Op, q.Dim)
+			}
+			v, err := c.Env.Eval(&expr.Unary{Op: n.Op, X: &expr.Num{Value: q.Value, Start: n.X.Pos(), Stop: n.X.End()}, Start: n.Start, Stop: n.Stop})
+			return Quantity{Value: v}, err
+		}
+		return q, nil
+	case *expr.Binary:
+		return c.binary(n)
+	case *expr.Call:
+		return c.call(n)
+	}
+	return Quantity{}, errorAt(n, "cannot evaluate")
+}
+
+func (c *Converter) binary(n *expr.Binary) (Quantity, error) {
+	x, err := c.Eval(n.X)
+	if err != nil {
+		return x, err
+	}
+	y, err := c.Eval(n.Y)
+	if err != nil {
+		return y, err
+	}
+
+	// A number followed by an offset unit is an absolute temperature
+	if n.Op == "*" && y.offset != nil && x.Dim.None() && x.offset == nil {
+		unit := y.offset
+		return Quantity{Value: x.Value*unit.Factor + unit.Offset, Dim: unit.Dim, Unit: unit, Absolute: true}, nil
+	}
+	x, y = settle(x), settle(y)
+
+	// Temperatures only add to differences, 30 degC - 20 degC and 5 K are
+	// ones
+	switch {
+	case n.Op == "+" && x.Absolute && y.Absolute:
+		return x, &expr.Error{Pos: n.X.Pos(), End: n.Y.End(), Msg: "cannot add two temperatures, one must be a difference like 30 degC - 20 degC"}
+	case n.Op == "-" && x.difference && y.Absolute:
+		return x, &expr.Error{Pos: n.X.Pos(), End: n.Y.End(), Msg: "cannot subtract a temperature from a difference"}
+	case n.Op != "+" && n.Op != "-" && (x.Absolute || y.Absolute):
+		return x, &expr.Error{Pos: n.X.Pos(), End: n.Y.End(), Msg: "cannot scale a temperature, only a difference like 30 degC - 20 degC"}
+	}
+
+	switch n.Op {
+	case "+", "-", "%":
+		if x.Dim != y.Dim {
+			verb := map[string]string{"+": "add", "-": "subtract", "%": "take the modulo of"}[n.Op]
+			return x, &expr.Error{Pos: n.X.Pos(), End: n.Y.End(), Msg: fmt.Sprintf("cannot %s %s and %s", verb, x.Dim, y.Dim)}
+		}
+		q := Quantity{Dim: x.Dim, Unit: x.Unit, difference: x.difference || y.difference}
+		switch n.Op {
+		case "+":
+			q.Value = x.Value + y.Value
+			// A temperature plus a difference is a temperature in its unit
+			q.Absolute = x.Absolute || y.Absolute
+			if y.Absolute {
+				q.Unit = y.Unit
+			}
+			q.difference = q.difference && !q.Absolute
+		case "-":
+			q.Value = x.Value - y.Value
+			q.Absolute = x.Absolute && !y.Absolute
+			q.difference = q.difference && !q.Absolute
+			// Two readings are apart by a difference, the K of 300 K - 20 degC
+			// is a reading
+			if y.Absolute {
+				q.difference = true
+				q.Value = roundTo(q.Value, math.Max(math.Abs(x.Value), math.Abs(y.Value)))
+			}
+		default:
+			if y.Value == 0 {
+				return q, errorAt(n.Y, "modulo by zero")
+			}
+			q.Value = math.Mod(x.Value, y.Value)
+		}
+		if q.Unit == nil {
+			q.Unit = y.Unit
+		}
+		return q, nil
+	case "*":
+		return Quantity{Value: x.Value * y.Value, Dim: x.Dim.add(y.Dim), Unit: combine(x, y, "*"), difference: x.difference || y.difference}, nil
+	case "/":
+		if y.Value == 0 {
+			return x, errorAt(n.Y, "division by zero")
+		}
+		return Quantity{Value: x.Value / y.Value, Dim: x.Dim.sub(y.Dim), Unit: combine(x, y, "/"), difference: x.difference || y.difference}, nil
+	case "^":
+		if !y.Dim.None() {
+			return x, errorAt(n.Y, "an exponent cannot have a unit")
+		}
+		q := Quantity{Value: math.Pow(x.Value, y.Value), difference: x.difference}
+		if !x.Dim.None() {
+			k := int(y.Value)
+			if float64(k) != y.Value {
+				return x, errorAt(n.Y, "units can only be raised to whole powers")
+			}
+			q.Dim = x.Dim.scale(k)
+			if x.Unit != nil {
+				q.Unit = &Unit{Symbol: x.Unit.Symbol + "^" + expr.Format(y.Value), Factor: math.Pow(x.Unit.Factor, y.Value), Dim: q.Dim}
+			}
+		}
+		return q, nil
+	}
+	if !x.Dim.None() || !y.Dim.None() {
+		return x, &expr.Error{Pos: n.X.Pos(), End: n.Y.End(), Msg: fmt.Sprintf("%s needs plain numbers", n.Op)}
+	}
+	v, err := c.Env.Eval(&expr.Binary{Op: n.Op, X: &expr.Num{Value: x.Value, Start: n.X.Pos(), Stop: n.X.End()}, Y: &expr.Num{Value: y.Value, Start: n.Y.Pos(), Stop: n.Y.End()}, OpPos: n.OpPos})
+	return Quantity{Value: v}, err
+}
+
+// combine returns the unit of x op y, like km/h, when both have a unit or
+// the unit of the one that has
+func combine(x, y Quantity, op string) *Unit {
+	switch {
+	case x.Unit != nil && y.Unit != nil:
+		symbol := x.Unit.Symbol + "·" + y.Unit.Symbol
+		factor := x.Unit.Factor * y.Unit.Factor
+		if x.Unit.Symbol == y.Unit.Symbol {
+			symbol = x.Unit.Symbol + "^2"
+		}
+		if op == "/" {
+			symbol = x.Unit.Symbol + "/" + y.Unit.Symbol
+			factor = x.Unit.Factor / y.Unit.Factor
+		}
+		dim := x.Dim.add(y.Dim)
+		if op == "/" {
+			dim = x.Dim.sub(y.Dim)
+		}
+		return &Unit{Symbol: symbol, Factor: factor, Dim: dim}
+	case x.Unit != nil:
+		return x.Unit
+	case y.Unit != nil && op == "*":
+		return y.Unit
+	}
+	return nil
+}
+
+func (c *Converter) call(n *expr.Call) (Quantity, error) {
+	args := make([]Quantity, len(n.Args))
+	for i, a := range n.Args {
+		q, err := c.Eval(a)
+		if err != nil {
+			return q, err
+		}
+		args[i] = settle(q)
+	}
+
+	if len(args) == 1 && !args[0].Dim.None() {
+		q := args[0]
+		switch n.Name {
+		case "abs":
+			q.Value = math.Abs(q.Value)
+			return q, nil
+		case "sqrt":
+			for _, p := range q.Dim {
+				if p%2 != 0 {
+					return q, errorAt(n, "the square root of %s has no unit", q.Dim)
+				}
+			}
+			return Quantity{Value: math.Sqrt(q.Value), Dim: q.Dim.half()}, nil
+		}
+	}
+	values := make([]expr.Node, len(args))
+	for i, q := range args {
+		if !q.Dim.None() {
+			return q, errorAt(n.Args[i], "%s needs a plain number, not %s", n.Name, q.Dim)
+		}
+		values[i] = &expr.Num{Value: q.Value, Start: n.Args[i].Pos(), Stop: n.Args[i].End()}
+	}
+	v, err := c.Env.Eval(&expr.Call{Name: n.Name, Args: values, Start: n.Start, Stop: n.Stop})
+	return Quantity{Value: v}, err
+}
+
+func (d Dim) half() Dim {
+	for i := range d {
+		d[i] /= 2
+	}
+	return d
+}
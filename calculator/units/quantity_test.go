@@ -0,0 +1,65 @@
+package units
+
+import (
+	"strings"
+	"testing"
+
+	"calculator/expr"
+)
+
+func TestTemperatures(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"300 K in degC", "26.85 degC"},
+		{"273.15 K to degF", "32 degF"},
+		{"20 degC in K", "293.15 K"},
+		{"68 degF in degC", "20 degC"},
+		{"-5 degC in degF", "23 degF"},
+		{"20 degC + (30 degC - 20 degC)", "30 degC"},
+		{"(30 degC - 20 degC) + 20 degC", "30 degC"},
+		{"20 degC - 10 degC", "10 degC"},
+		{"(30 degC - 20 degC) * 2", "20 degC"},
+		{"300 K - 20 degC", "6.85 K"},
+		{"3 (K/s) * 2 s in K", "6 K"},
+		{"20 degC + 5 K", "25 degC"},
+		{"5 K / 2", "2.5 K"},
+		{"3 K/s * 2 s in K", "6 K"},
+		{"300 K + 300 K", "600 K"},
+		{"(30 degC - 20 degC) in degF", "18 degF"},
+		{"3 (degC/s) * 2 s in degF", "10.8 degF"},
+	}
+	c := &Converter{Env: expr.NewEnv()}
+	for _, test := range tests {
+		r, err := c.Evaluate(test.in)
+		if err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+		if got := expr.Format(r.Value) + " " + r.Unit; got != test.want {
+			t.Errorf("%s = %s, want %s", test.in, got, test.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"10 degC + 5 degC", "cannot add two temperatures"},
+		{"20 degC * 2", "cannot scale a temperature"},
+		{"2 * 20 degC", "cannot scale a temperature"},
+		{"(20 degC) ^ 2", "cannot scale a temperature"},
+		{"(30 degC - 20 degC) - 20 degC", "cannot subtract a temperature"},
+		{"1e400 m", "1e400 is too large"},
+	}
+	c := &Converter{Env: expr.NewEnv()}
+	for _, test := range tests {
+		r, err := c.Evaluate(test.in)
+		if err == nil {
+			t.Errorf("%s = %v, want an error", test.in, r)
+		} else if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %v, want %q", test.in, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Package units is the unit catalogue and the unit-aware evaluator of the
+// calculator's conversion mode.
+//
+// Quantities are kept in SI base units (bytes for data, US dollars for
+// money) with the powers of each base dimension, so "5 km + 300 m in mi"
+// adds two lengths and converts the sum to miles while "5 km + 3 kg" is an
+// error.
+package units
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Base dimensions
+const (
+	Length = iota
+	Mass
+	Time
+	Temperature
+	Data
+	Currency
+	dimensions
+)
+
+// baseSymbols are the units of the base dimensions
+var baseSymbols = [dimensions]string{"m", "kg", "s", "K", "B", "USD"}
+
+// Dim is the power of each base dimension of a quantity, all zero for
+// plain numbers
+type Dim [dimensions]int
+
+// None reports whether d is dimensionless
+func (d Dim) None() bool {
+	return d == Dim{}
+}
+
+func (d Dim) add(o Dim) Dim {
+	for i := range d {
+		d[i] += o[i]
+	}
+	return d
+}
+
+func (d Dim) sub(o Dim) Dim {
+	for i := range d {
+		d[i] -= o[i]
+	}
+	return d
+}
+
+func (d Dim) scale(k int) Dim {
+	for i := range d {
+		d[i] *= k
+	}
+	return d
+}
+
+// String names d after its category, like "length", or writes it in base
+// units, like "m·kg"
+func (d Dim) String() string {
+	if d.None() {
+		return "a plain number"
+	}
+	for _, c := range Categories {
+		if c.Dim == d {
+			return strings.ToLower(c.Name)
+		}
+	}
+	return d.symbol()
+}
+
+// symbol writes d in base units, like m/s^2
+func (d Dim) symbol() string {
+	var num, den []string
+	for i, p := range d {
+		switch {
+		case p == 1:
+			num = append(num, baseSymbols[i])
+		case p > 1:
+			num = append(num, baseSymbols[i]+"^"+strconv.Itoa(p))
+		case p == -1:
+			den = append(den, baseSymbols[i])
+		case p < -1:
+			den = append(den, baseSymbols[i]+"^"+strconv.Itoa(-p))
+		}
+	}
+	s := strings.Join(num, "·")
+	if s == "" {
+		s = "1"
+	}
+	if len(den) > 0 {
+		s += "/" + strings.Join(den, "·")
+	}
+	return s
+}
+
+// Unit is a unit of the catalogue. A value v of the unit is v*Factor+Offset
+// in base units, only temperatures have an Offset
+type Unit struct {
+	Symbol   string
+	Name     string
+	Aliases  []string
+	Category string
+	Factor   float64
+	Offset   float64
+	Dim      Dim
+}
+
+// Category is a group of units of the same dimension
+type Category struct {
+	Name string
+	Dim  Dim
+}
+
+func dim(powers ...int) Dim {
+	var d Dim
+	copy(d[:], powers)
+	return d
+}
+
+// Categories of the catalogue in the order they are listed
+var Categories = []Category{
+	{"Length", dim(1)},
+	{"Area", dim(2)},
+	{"Volume", dim(3)},
+	{"Mass", dim(0, 1)},
+	{"Time", dim(0, 0, 1)},
+	{"Temperature", dim(0, 0, 0, 1)},
+	{"Data", dim(0, 0, 0, 0, 1)},
+	{"Speed", dim(1, 0, -1)},
+	{"Frequency", dim(0, 0, -1)},
+	{"Force", dim(1, 1, -2)},
+	{"Energy", dim(2, 1, -2)},
+	{"Power", dim(2, 1, -3)},
+	{"Pressure", dim(-1, 1, -2)},
+	{"Currency", dim(0, 0, 0, 0, 0, 1)},
+}
+
+func category(name string) Dim {
+	for _, c := range Categories {
+		if c.Name == name {
+			return c.Dim
+		}
+	}
+	panic("units: unknown category " + name)
+}
+
+// u declares a unit of a category
+func u(cat, symbol, name string, factor float64, aliases ...string) *Unit {
+	return &Unit{Symbol: symbol, Name: name, Aliases: aliases, Category: cat, Factor: factor, Dim: category(cat)}
+}
+
+var builtin = []*Unit{
+	u("Length", "m", "metre", 1, "meter", "meters", "metres"),
+	u("Length", "km", "kilometre", 1e3, "kilometer", "kilometers"),
+	u("Length", "cm", "centimetre", 1e-2),
+	u("Length", "mm", "millimetre", 1e-3),
+	u("Length", "um", "micrometre", 1e-6, "µm"),
+	u("Length", "nm", "nanometre", 1e-9),
+	u("Length", "mi", "mile", 1609.344, "mile", "miles"),
+	u("Length", "yd", "yard", 0.9144, "yard", "yards"),
+	u("Length", "ft", "foot", 0.3048, "foot", "feet"),
+	u("Length", "in", "inch", 0.0254, "inch", "inches"),
+	u("Length", "nmi", "nautical mile", 1852),
+
+	u("Area", "m2", "square metre", 1),
+	u("Area", "km2", "square kilometre", 1e6),
+	u("Area", "ha", "hectare", 1e4, "hectare", "hectares"),
+	u("Area", "acre", "acre", 4046.8564224, "acres"),
+
+	u("Volume", "m3", "cubic metre", 1),
+	u("Volume", "L", "litre", 1e-3, "l", "liter", "litre", "liters", "litres"),
+	u("Volume", "mL", "millilitre", 1e-6, "ml"),
+	u("Volume", "gal", "US gallon", 3.785411784e-3, "gallon", "gallons"),
+	u("Volume", "qt", "US quart", 9.46352946e-4),
+	u("Volume", "pt", "US pint", 4.73176473e-4),
+	u("Volume", "cup", "US cup", 2.365882365e-4, "cups"),
+	u("Volume", "floz", "US fluid ounce", 2.95735295625e-5),
+
+	u("Mass", "kg", "kilogram", 1, "kilogram", "kilograms"),
+	u("Mass", "g", "gram", 1e-3, "gram", "grams"),
+	u("Mass", "mg", "milligram", 1e-6),
+	u("Mass", "t", "tonne", 1e3, "tonne", "tonnes"),
+	u("Mass", "lb", "pound", 0.45359237, "lbs", "pound", "pounds"),
+	u("Mass", "oz", "ounce", 0.028349523125, "ounce", "ounces"),
+	u("Mass", "st", "stone", 6.35029318),
+
+	u("Time", "s", "second", 1, "sec", "second", "seconds"),
+	u("Time", "ms", "millisecond", 1e-3),
+	u("Time", "us", "microsecond", 1e-6, "µs"),
+	u("Time", "ns", "nanosecond", 1e-9),
+	u("Time", "min", "minute", 60, "minute", "minutes"),
+	u("Time", "h", "hour", 3600, "hr", "hour", "hours"),
+	u("Time", "d", "day", 86400, "day", "days"),
+	u("Time", "wk", "week", 604800, "week", "weeks"),
+	u("Time", "yr", "year", 31557600, "year", "years"),
+
+	u("Temperature", "K", "kelvin", 1, "kelvin"),
+	{Symbol: "degC", Name: "degree Celsius", Aliases: []string{"celsius"}, Category: "Temperature", Factor: 1, Offset: 273.15, Dim: category("Temperature")},
+	{Symbol: "degF", Name: "degree Fahrenheit", Aliases: []string{"fahrenheit"}, Category: "Temperature", Factor: 5.0 / 9, Offset: 459.67 * 5 / 9, Dim: category("Temperature")},
+
+	u("Data", "bit", "bit", 0.125, "bits"),
+	u("Data", "B", "byte", 1, "byte", "bytes"),
+	u("Data", "kB", "kilobyte", 1e3),
+	u("Data", "MB", "megabyte", 1e6),
+	u("Data", "GB", "gigabyte", 1e9),
+	u("Data", "TB", "terabyte", 1e12),
+	u("Data", "PB", "petabyte", 1e15),
+	u("Data", "KiB", "kibibyte", 1<<10),
+	u("Data", "MiB", "mebibyte", 1<<20),
+	u("Data", "GiB", "gibibyte", 1<<30),
+	u("Data", "TiB", "tebibyte", 1<<40),
+	u("Data", "Mbit", "megabit", 1.25e5),
+	u("Data", "Gbit", "gigabit", 1.25e8),
+
+	u("Speed", "kph", "kilometre per hour", 1/3.6, "kmh"),
+	u("Speed", "mph", "mile per hour", 0.44704),
+	u("Speed", "kn", "knot", 1852.0/3600, "knot", "knots"),
+
+	u("Frequency", "Hz", "hertz", 1),
+	u("Frequency", "kHz", "kilohertz", 1e3),
+	u("Frequency", "MHz", "megahertz", 1e6),
+	u("Frequency", "GHz", "gigahertz", 1e9),
+
+	u("Force", "N", "newton", 1),
+	u("Force", "kN", "kilonewton", 1e3),
+	u("Force", "lbf", "pound-force", 4.4482216152605),
+
+	u("Energy", "J", "joule", 1),
+	u("Energy", "kJ", "kilojoule", 1e3),
+	u("Energy", "cal", "calorie", 4.184),
+	u("Energy", "kcal", "kilocalorie", 4184),
+	u("Energy", "Wh", "watt hour", 3600),
+	u("Energy", "kWh", "kilowatt hour", 3.6e6),
+	u("Energy", "eV", "electronvolt", 1.602176634e-19),
+
+	u("Power", "W", "watt", 1),
+	u("Power", "kW", "kilowatt", 1e3),
+	u("Power", "MW", "megawatt", 1e6),
+	u("Power", "hp", "horsepower", 745.69987158227022),
+
+	u("Pressure", "Pa", "pascal", 1),
+	u("Pressure", "kPa", "kilopascal", 1e3),
+	u("Pressure", "bar", "bar", 1e5),
+	u("Pressure", "atm", "atmosphere", 101325),
+	u("Pressure", "psi", "pound per square inch", 6894.757293168),
+	u("Pressure", "mmHg", "millimetre of mercury", 133.322387415),
+}
+
+var (
+	mu         sync.RWMutex
+	currencies []*Unit
+	lookup     map[string]*Unit
+)
+
+func init() {
+	index()
+}
+
+// index rebuilds the lookup table, mu must be held for writing or not be
+// needed yet
+func index() {
+	lookup = make(map[string]*Unit)
+	for _, list := range [][]*Unit{builtin, currencies} {
+		for _, unit := range list {
+			lookup[unit.Symbol] = unit
+			for _, alias := range unit.Aliases {
+				lookup[alias] = unit
+			}
+		}
+	}
+}
+
+// Find returns the unit with the symbol or alias name
+func Find(name string) (*Unit, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	unit, ok := lookup[name]
+	return unit, ok
+}
+
+// InCategory returns the units of a category, currencies sorted by symbol
+func InCategory(name string) []*Unit {
+	mu.RLock()
+	defer mu.RUnlock()
+	if name == "Currency" {
+		return append([]*Unit{}, currencies...)
+	}
+	list := make([]*Unit, 0)
+	for _, unit := range builtin {
+		if unit.Category == name {
+			list = append(list, unit)
+		}
+	}
+	return list
+}
+
+// setCurrencies replaces the currency units
+func setCurrencies(list []*Unit) {
+	sort.Slice(list, func(i, j int) bool { return list[i].Symbol < list[j].Symbol })
+	mu.Lock()
+	defer mu.Unlock()
+	currencies = list
+	index()
+}
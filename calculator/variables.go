@@ -0,0 +1,290 @@
+package calculator
+
+import (
+	"encoding/json"
+	"image/color"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"sync"
+
+	"calculator/core"
+	"calculator/expr"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// namesFile keeps the variables and functions in the storage of the app
+const namesFile = "calculator-names.json"
+
+// names are the variables and functions of the user, shared by every
+// calculator window
+var names = &namesStore{vars: make(map[string]*big.Rat), complex: make(map[string]complex128), funcs: make(map[string]*expr.UserFunc), bases: make(map[string]int)}
+
+type namesStore struct {
+	mu        sync.Mutex
+	app       fyne.App
+	loaded    bool
+	vars      map[string]*big.Rat
+	complex   map[string]complex128
+	funcs     map[string]*expr.UserFunc
+	bases     map[string]int
+	listeners map[int]func()
+	nextID    int
+}
+
+// savedNames is the names file, Complex holds the variables that are not
+// real as a + bi
+type savedNames struct {
+	Vars    map[string]string
+	Complex map[string]string `json:",omitempty"`
+	Funcs   map[string]savedFunc
+}
+
+// savedFunc is a function as typed, Base is the base of its numbers
+type savedFunc struct {
+	Definition string
+	Base       int
+}
+
+// load reads the names from the storage of a the first time they are needed
+func (n *namesStore) load(a fyne.App) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.loaded {
+		return
+	}
+	n.app, n.loaded = a, true
+	reader, err := a.Storage().Open(namesFile)
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		fyne.LogError("Failed to read the calculator variables", err)
+		return
+	}
+	var saved savedNames
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fyne.LogError("Failed to read the calculator variables", err)
+		return
+	}
+	for name, value := range saved.Vars {
+		if r, ok := new(big.Rat).SetString(value); ok {
+			n.vars[name] = r
+		}
+	}
+	for name, value := range saved.Complex {
+		if z, err := core.ParseComplex(value); err == nil {
+			n.complex[name] = z
+		}
+	}
+	for name, f := range saved.Funcs {
+		a, err := expr.ParseAssignment(f.Definition, f.Base)
+		if err != nil || a == nil || !a.IsFunction() {
+			fyne.LogError("Failed to read the calculator function "+name, err)
+			continue
+		}
+		n.funcs[name] = &expr.UserFunc{Params: a.Params, Body: a.Body, Source: a.Source}
+		n.bases[name] = f.Base
+	}
+}
+
+// save writes the names, n.mu must be held
+func (n *namesStore) save() {
+	saved := savedNames{Vars: make(map[string]string), Complex: make(map[string]string), Funcs: make(map[string]savedFunc)}
+	for name, r := range n.vars {
+		saved.Vars[name] = r.RatString()
+	}
+	for name, z := range n.complex {
+		saved.Complex[name] = expr.FormatComplex(z)
+	}
+	for name, f := range n.funcs {
+		saved.Funcs[name] = savedFunc{Definition: f.String(name), Base: n.bases[name]}
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		fyne.LogError("Failed to save the calculator variables", err)
+		return
+	}
+	writer, err := n.app.Storage().Save(namesFile)
+	if err != nil {
+		writer, err = n.app.Storage().Create(namesFile)
+		if err != nil {
+			fyne.LogError("Failed to save the calculator variables", err)
+			return
+		}
+	}
+	defer writer.Close()
+	if _, err := writer.Write(data); err != nil {
+		fyne.LogError("Failed to save the calculator variables", err)
+	}
+}
+
+// change applies f to the names, saves them and tells the listeners
+func (n *namesStore) change(f func()) {
+	n.mu.Lock()
+	f()
+	n.save()
+	listeners := make([]func(), 0, len(n.listeners))
+	for _, l := range n.listeners {
+		listeners = append(listeners, l)
+	}
+	n.mu.Unlock()
+	for _, l := range listeners {
+		l()
+	}
+}
+
+// set assigns a variable, replacing a function of the same name
+func (n *namesStore) set(name string, r *big.Rat) {
+	n.change(func() {
+		delete(n.funcs, name)
+		delete(n.complex, name)
+		n.vars[name] = new(big.Rat).Set(r)
+	})
+}
+
+// setComplex assigns a variable that is not real
+func (n *namesStore) setComplex(name string, z complex128) {
+	n.change(func() {
+		delete(n.funcs, name)
+		delete(n.vars, name)
+		n.complex[name] = z
+	})
+}
+
+// define adds a function, replacing a variable of the same name
+func (n *namesStore) define(name string, f *expr.UserFunc, base int) {
+	n.change(func() {
+		delete(n.vars, name)
+		delete(n.complex, name)
+		n.funcs[name] = f
+		n.bases[name] = base
+	})
+}
+
+// remove deletes a variable or function
+func (n *namesStore) remove(name string) {
+	n.change(func() {
+		delete(n.vars, name)
+		delete(n.complex, name)
+		delete(n.funcs, name)
+		delete(n.bases, name)
+	})
+}
+
+// apply gives e the current variables and functions
+func (n *namesStore) apply(e *engine) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// Complex mode has every variable, real or not
+	for name := range e.Cplx.Vars {
+		_, isReal := n.vars[name]
+		_, isComplex := n.complex[name]
+		if !isReal && !isComplex && name != "Ans" {
+			e.UnsetVar(name)
+		}
+	}
+	for name, r := range n.vars {
+		e.SetVar(name, r)
+	}
+	for name, z := range n.complex {
+		e.SetComplexVar(name, z)
+	}
+	funcs := make(map[string]*expr.UserFunc, len(n.funcs))
+	for name, f := range n.funcs {
+		funcs[name] = f
+	}
+	e.Env.UserFuncs = funcs
+	e.Prog.UserFuncs = funcs
+}
+
+// nameItem is a row of the variables panel
+type nameItem struct {
+	name   string
+	text   string
+	insert string
+}
+
+// list returns the variables, then the functions, sorted by name
+func (n *namesStore) list() []nameItem {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	items := make([]nameItem, 0, len(n.vars)+len(n.complex)+len(n.funcs))
+	for name, r := range n.vars {
+		v, _ := r.Float64()
+		items = append(items, nameItem{name, name + " = " + expr.Format(v), name})
+	}
+	for name, z := range n.complex {
+		items = append(items, nameItem{name, name + " = " + expr.FormatComplex(z), name})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].name < items[j].name })
+	funcs := make([]nameItem, 0, len(n.funcs))
+	for name, f := range n.funcs {
+		funcs = append(funcs, nameItem{name, f.String(name), name + "("})
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].name < funcs[j].name })
+	return append(items, funcs...)
+}
+
+// onChanged registers f to be called whenever a name changes until the
+// returned func is called
+func (n *namesStore) onChanged(f func()) func() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.listeners == nil {
+		n.listeners = make(map[int]func())
+	}
+	n.nextID++
+	id := n.nextID
+	n.listeners[id] = f
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.listeners, id)
+	}
+}
+
+// variablesPanel returns the side panel listing the variables and functions,
+// tapping one inserts its name into the input. The list follows the names
+// until stop is called
+func variablesPanel(insert func(string)) (panel fyne.CanvasObject, stop func()) {
+	items := names.list()
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			return container.NewHBox(
+				widget.NewLabel(""),
+				layout.NewSpacer(),
+				widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
+			)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			row := item.(*fyne.Container)
+			name := items[id].name
+			row.Objects[0].(*widget.Label).SetText(items[id].text)
+			row.Objects[2].(*widget.Button).OnTapped = func() { names.remove(name) }
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		insert(items[id].insert)
+		list.Unselect(id)
+	}
+	stop = names.onChanged(func() {
+		items = names.list()
+		list.Refresh()
+	})
+
+	// The list has no width of its own in the side panel
+	size := canvas.NewRectangle(color.Transparent)
+	size.SetMinSize(fyne.NewSize(220, 0))
+	help := widget.NewLabel("x = 3 assigns a variable\nf(x) = x^2 + 1 defines a function")
+	help.TextStyle = fyne.TextStyle{Italic: true}
+	return container.NewBorder(widget.NewLabel("Variables"), help, nil, nil, container.NewMax(size, list)), stop
+}
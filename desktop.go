@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"varos/registry"
+	"varos/wallpaper"
+)
+
+// Size of a desktop icon cell
+const (
+	cellWidth  = 88
+	cellHeight = 84
+)
+
+const iconsKey = "desktop.icons" // Preference with the icon cells
+
+// cell is a column and row of the desktop icon grid
+type cell struct {
+	Col int `json:"col"`
+	Row int `json:"row"`
+}
+
+// Desktop is the wallpaper with the app icons on top, icons can be dragged
+// to any cell and stay there
+type Desktop struct {
+	launcher *Launcher
+	cells    map[string]cell
+	icons    *fyne.Container
+	root     *fyne.Container
+}
+
+func NewDesktop(l *Launcher) *Desktop {
+	d := &Desktop{launcher: l, cells: map[string]cell{}}
+	json.Unmarshal([]byte(fyne.CurrentApp().Preferences().String(iconsKey)), &d.cells)
+	d.icons = container.New(&iconGrid{desktop: d})
+	for _, app := range registry.Apps() {
+		if app.Desktop {
+			d.icons.Add(newDesktopIcon(d, app))
+		}
+	}
+	d.root = container.NewMax(wallpaper.Get().Object(), d.icons)
+	wallpaper.OnChanged(func(wp wallpaper.Wallpaper) {
+		d.root.Objects[0] = wp.Object()
+		d.root.Refresh()
+	})
+	return d
+}
+
+// Content returns the desktop canvas object
+func (d *Desktop) Content() fyne.CanvasObject {
+	return d.root
+}
+
+// place puts the icon of app in the free cell nearest to pos and saves the grid
+func (d *Desktop) place(app *registry.App, pos fyne.Position) {
+	want := cell{
+		Col: int(math.Round(float64(pos.X / cellWidth))),
+		Row: int(math.Round(float64(pos.Y / cellHeight))),
+	}
+	rows := d.rows()
+	if want.Col < 0 {
+		want.Col = 0
+	}
+	if want.Row < 0 {
+		want.Row = 0
+	}
+	if want.Row >= rows {
+		want.Row = rows - 1
+	}
+	delete(d.cells, app.ID)
+	for d.taken(want) {
+		want.Row++
+		if want.Row >= rows {
+			want.Row = 0
+			want.Col++
+		}
+	}
+	d.cells[app.ID] = want
+	if data, err := json.Marshal(d.cells); err == nil {
+		fyne.CurrentApp().Preferences().SetString(iconsKey, string(data))
+	}
+	d.icons.Refresh()
+}
+
+func (d *Desktop) taken(c cell) bool {
+	for _, used := range d.cells {
+		if used == c {
+			return true
+		}
+	}
+	return false
+}
+
+// rows returns how many icon rows fit on the desktop
+func (d *Desktop) rows() int {
+	rows := int(d.icons.Size().Height / cellHeight)
+	if rows < 1 {
+		return 1
+	}
+	return rows
+}
+
+// iconGrid lays desktop icons out on their cells, icons without a saved cell
+// fill the first free cells down the columns
+type iconGrid struct {
+	desktop *Desktop
+}
+
+func (g *iconGrid) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	d := g.desktop
+	rows := int(size.Height / cellHeight)
+	if rows < 1 {
+		rows = 1
+	}
+	next := cell{}
+	for _, o := range objects {
+		icon := o.(*desktopIcon)
+		c, ok := d.cells[icon.app.ID]
+		if !ok {
+			for d.taken(next) {
+				next.Row++
+				if next.Row >= rows {
+					next.Row = 0
+					next.Col++
+				}
+			}
+			c = next
+			d.cells[icon.app.ID] = c
+		}
+		icon.Resize(fyne.NewSize(cellWidth, cellHeight))
+		icon.Move(fyne.NewPos(float32(c.Col)*cellWidth, float32(c.Row)*cellHeight))
+	}
+}
+
+func (g *iconGrid) MinSize([]fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(cellWidth, cellHeight)
+}
+
+// desktopIcon is an app icon with its name below, tapping opens the app
+type desktopIcon struct {
+	widget.BaseWidget
+	desktop *Desktop
+	app     *registry.App
+}
+
+func newDesktopIcon(d *Desktop, app *registry.App) *desktopIcon {
+	i := &desktopIcon{desktop: d, app: app}
+	i.ExtendBaseWidget(i)
+	return i
+}
+
+func (i *desktopIcon) CreateRenderer() fyne.WidgetRenderer {
+	icon := widget.NewIcon(i.app.Icon)
+	label := widget.NewLabelWithStyle(i.app.Name, fyne.TextAlignCenter, fyne.TextStyle{})
+	box := container.NewBorder(nil, label, nil, nil, container.NewPadded(icon))
+	return widget.NewSimpleRenderer(box)
+}
+
+func (i *desktopIcon) Tapped(*fyne.PointEvent) {
+	i.desktop.launcher.Open(i.app)
+}
+
+func (i *desktopIcon) Dragged(e *fyne.DragEvent) {
+	i.Move(i.Position().Add(e.Dragged))
+}
+
+func (i *desktopIcon) DragEnd() {
+	i.desktop.place(i.app, i.Position())
+}
+
+func (i *desktopIcon) MinSize() fyne.Size {
+	return fyne.NewSize(cellWidth, cellHeight)
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"gallery"
+
+	"fyne.io/fyne/v2/app"
+)
+
+func main() {
+	a := app.NewWithID("io.varos.gallery")
+	w := a.NewWindow("Gallery")
+	w.SetContent(gallery.New(a, w))
+	w.ShowAndRun()
+}
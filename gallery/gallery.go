@@ -1,41 +1,25 @@
-package main
+package gallery
 
 import (
-	"image/color"
+	_ "embed"
 	"io/ioutil"
 	"strings"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"varos/recent"
+	"varos/registry"
+	"varos/session"
+	"varos/ui"
+	"varos/wallpaper"
 )
 
-func BoxLayout(box string) fyne.Layout {
-	layouts := map[string]fyne.Layout{
-		"H": layout.NewHBoxLayout(),
-		"V": layout.NewVBoxLayout(),
-	}
-	return layouts[box]
-}
-
-func GridLayout(box string, n int) fyne.Layout {
-	layouts := map[string]fyne.Layout{
-		"":  layout.NewGridLayout(n),
-		"C": layout.NewGridLayoutWithColumns(n),
-		"R": layout.NewGridLayoutWithRows(n),
-	}
-	return layouts[box]
-}
-
-func Text(str string, r uint8, g uint8, b uint8, a uint8) *canvas.Text {
-	return canvas.NewText(str, color.RGBA{R: r, G: g, B: b, A: a})
-}
-
 func ImageFileList(dir string) (int, []string) {
 	files, err := ioutil.ReadDir(dir)
 	fileList := make([]string, 0)
@@ -84,51 +68,88 @@ func ContImgList(img []fyne.CanvasObject, target *int, imgViewer *fyne.Container
 			imgViewer.AddObject(img)
 			imgViewer.Objects = imgViewer.Objects[1:]
 			nameTile.SetText(imgFileList[index])
+			recent.AddDocument("gallery", storage.NewFileURI(imgFileList[index]))
 		}), i))
 	}
 	return cont
 }
 
 func CrateGallery(dir string, fileChoser fyne.Widget) *fyne.Container {
-	targetImage := 0
-	lightTheme := true
-	themeOptions := map[bool]fyne.Theme{
-		false: theme.DarkTheme(),
-		true:  theme.LightTheme()}
-	themeBtn := widget.NewButtonWithIcon("", theme.ColorPaletteIcon(), func() {
-		lightTheme = !lightTheme
-		fyne.CurrentApp().Settings().SetTheme(themeOptions[lightTheme])
-	})
+	return crateGallery(&galleryState{Dir: dir}, fileChoser)
+}
+
+// CrateGalleryAt is CrateGallery showing the image named file first
+func CrateGalleryAt(dir string, file string, fileChoser fyne.Widget) *fyne.Container {
+	return crateGallery(galleryStateAt(dir, file), fileChoser)
+}
+
+// galleryState is the folder and image a gallery window shows, it is kept
+// in the VarOS session
+type galleryState struct {
+	Dir   string
+	Index int
+}
+
+func galleryStateAt(dir string, file string) *galleryState {
+	state := &galleryState{Dir: dir}
+	_, imgFileList := ImageFileList(dir)
+	for i, name := range imgFileList {
+		if name == dir+file {
+			state.Index = i
+		}
+	}
+	return state
+}
+
+func crateGallery(state *galleryState, fileChoser fyne.Widget) *fyne.Container {
+	dir := state.Dir
 	numOfImg, imgFileList := ImageFileList(dir)
+	if state.Index >= numOfImg {
+		state.Index = 0
+	}
 	imageList := FileImageTile(imgFileList)
-	showImage := canvas.NewImageFromFile(imgFileList[targetImage])
+	showImage := canvas.NewImageFromFile(imgFileList[state.Index])
 	showImage.SetMinSize(fyne.NewSize(400, 400))
 	showImage.FillMode = canvas.ImageFillContain
 	imgViewer := container.NewVBox(showImage)
-	nameTile := widget.NewLabel(imgFileList[targetImage])
+	nameTile := widget.NewLabel(imgFileList[state.Index])
 	prevImgBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
-		if targetImage > 0 {
-			targetImage--
-			img := canvas.NewImageFromFile(imgFileList[targetImage])
+		if state.Index > 0 {
+			state.Index--
+			img := canvas.NewImageFromFile(imgFileList[state.Index])
 			img.SetMinSize(fyne.NewSize(400, 400))
 			img.FillMode = canvas.ImageFillContain
 			imgViewer.AddObject(img)
 			imgViewer.Objects = imgViewer.Objects[1:]
-			nameTile.SetText(imgFileList[targetImage])
+			nameTile.SetText(imgFileList[state.Index])
 		}
 	})
 	nextImgBtn := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
-		if targetImage < numOfImg-1 {
-			targetImage++
-			img := canvas.NewImageFromFile(imgFileList[targetImage])
+		if state.Index < numOfImg-1 {
+			state.Index++
+			img := canvas.NewImageFromFile(imgFileList[state.Index])
 			img.SetMinSize(fyne.NewSize(400, 400))
 			img.FillMode = canvas.ImageFillContain
 			imgViewer.AddObject(img)
 			imgViewer.Objects = imgViewer.Objects[1:]
-			nameTile.SetText(imgFileList[targetImage])
+			nameTile.SetText(imgFileList[state.Index])
+		}
+	})
+	var wallpaperBtn *widget.Button
+	wallpaperBtn = widget.NewButtonWithIcon("", theme.MediaPhotoIcon(), func() {
+		fills := [][2]string{{"Stretch", "stretch"}, {"Contain", "contain"}, {"Original Size", "original"}}
+		menu := fyne.NewMenu("Set as Wallpaper")
+		for _, fill := range fills {
+			fill := fill
+			menu.Items = append(menu.Items, fyne.NewMenuItem("Set as Wallpaper ("+fill[0]+")", func() {
+				wallpaper.SetImage(imgFileList[state.Index], fill[1])
+			}))
 		}
+		c := fyne.CurrentApp().Driver().CanvasForObject(wallpaperBtn)
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(wallpaperBtn)
+		widget.ShowPopUpMenuAtPosition(menu, c, pos.Add(fyne.NewPos(0, wallpaperBtn.Size().Height)))
 	})
-	contImgList := ContImgList(imageList, &targetImage, imgViewer, imgFileList, nameTile)
+	contImgList := ContImgList(imageList, &state.Index, imgViewer, imgFileList, nameTile)
 
 	imgTileLayout := container.NewHBox()
 	for _, img := range contImgList {
@@ -137,7 +158,7 @@ func CrateGallery(dir string, fileChoser fyne.Widget) *fyne.Container {
 	imageTileSrollBar := container.NewHScroll(imgTileLayout)
 	imageTileSrollBar.SetMinSize(fyne.Size{Width: 450})
 	c := container.NewVBox(
-		container.NewHBox(fileChoser, nameTile, layout.NewSpacer(), themeBtn),
+		ui.Toolbar(fileChoser, wallpaperBtn, nameTile),
 		layout.NewSpacer(),
 		container.NewHBox(
 			prevImgBtn,
@@ -156,28 +177,47 @@ func CrateGallery(dir string, fileChoser fyne.Widget) *fyne.Container {
 	return c
 }
 
-func main() {
-	app := app.New().NewWindow("Gallery")
-	dir := "C:/msys64/home/Niranjan/goproject/gallery/imagegallery/"
-	c := CrateGallery(dir, fileChoserBtn(app))
-	app.SetContent(c)
-	app.Resize(fyne.NewSize(650, 500))
-	app.ShowAndRun()
+//go:embed manifest.json
+var manifest []byte
+
+func init() {
+	registry.MustRegisterWithFiles(manifest, New, OpenFile)
+}
+
+// New builds the gallery inside w and returns its content
+func New(a fyne.App, w fyne.Window) fyne.CanvasObject {
+	state := &galleryState{Dir: "C:/msys64/home/Niranjan/goproject/gallery/imagegallery/"}
+	session.Restore(w, state)
+	session.Track(w, func() interface{} { return state })
+	c := crateGallery(state, fileChoserBtn(w, state))
+	w.Resize(fyne.NewSize(650, 500))
+	return c
+}
+
+// OpenFile builds the gallery inside w showing the image at uri
+func OpenFile(a fyne.App, w fyne.Window, uri fyne.URI) fyne.CanvasObject {
+	state := galleryStateAt(strings.TrimSuffix(uri.Path(), uri.Name()), uri.Name())
+	session.Track(w, func() interface{} { return state })
+	c := crateGallery(state, fileChoserBtn(w, state))
+	w.Resize(fyne.NewSize(650, 500))
+	return c
 }
 
-func fileChoserBtn(app fyne.Window) fyne.Widget {
+func fileChoserBtn(app fyne.Window, state *galleryState) fyne.Widget {
 	fileChoser := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
 		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
 			save_dir := ""
 			if err != nil {
-				dialog.ShowError(err, app)
+				ui.ShowError(err, app)
 				return
 			}
 			if dir != nil {
 				save_dir = dir.Path()
 			}
 			if save_dir != "" {
-				app.SetContent(CrateGallery(save_dir+"/", fileChoserBtn(app)))
+				state.Dir = save_dir + "/"
+				state.Index = 0
+				app.SetContent(crateGallery(state, fileChoserBtn(app, state)))
 				app.Content().Refresh()
 			}
 		}, app)
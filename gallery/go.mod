@@ -23,3 +23,7 @@ require (
 	golang.org/x/text v0.3.3 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
+
+require varos v0.0.0
+
+replace varos => ../varos
@@ -23,3 +23,19 @@ require (
 	golang.org/x/text v0.3.3 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
 )
+
+require (
+	calculator v0.0.0
+	gallery v0.0.0
+	texteditor v0.0.0
+	varos v0.0.0
+	weather v0.0.0
+)
+
+replace (
+	calculator => ./calculator
+	gallery => ./gallery
+	texteditor => ./texteditor
+	varos => ./varos
+	weather => ./weather
+)
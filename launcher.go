@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"varos/recent"
+	"varos/registry"
+	"varos/wm"
+)
+
+// Launcher opens registered apps through the window manager
+type Launcher struct {
+	wm *wm.Manager
+}
+
+func NewLauncher(m *wm.Manager) *Launcher {
+	return &Launcher{wm: m}
+}
+
+// Open starts app, or focuses it when it is single instance and running
+func (l *Launcher) Open(app *registry.App) {
+	recent.AddApp(app.ID)
+	l.wm.Open(app)
+}
+
+// OpenFile starts app with the file at uri
+func (l *Launcher) OpenFile(app *registry.App, uri fyne.URI) {
+	recent.AddApp(app.ID)
+	recent.AddDocument(app.ID, uri)
+	l.wm.OpenFile(app, uri)
+}
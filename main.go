@@ -3,18 +3,31 @@ package main
 import (
 	"fmt"
 	"image/color"
+	"time"
 
+	_ "calculator"
+	_ "gallery"
+	_ "texteditor"
+	_ "varos/settings"
+	_ "weather"
+
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"varos/session"
+	"varos/themes"
+	"varos/wm"
 )
 
 func main() {
-	a := app.New()            // Crate New App
-	w := a.NewWindow("VarOS") // Create New Window
+	a := app.NewWithID("io.varos.desktop") // Crate New App
+	w := a.NewWindow("VarOS")              // Create New Window
+	started := time.Now()                  // Session Start
+	themes.Restore()                       // Theme chosen in Settings
 
 	//Test Object
 	var text [4]*canvas.Text
@@ -23,33 +36,60 @@ func main() {
 	}
 
 	// Box Layout
-	hbox := layout.NewHBoxLayout() // Horizontal Box Layout
 	vbox := layout.NewVBoxLayout() // Vertical Box Layout
 
-	// Left Layout
-	leftBox := container.New(
-		vbox,
-		widget.NewIcon(theme.FileApplicationIcon()),
-		widget.NewIcon(theme.FileApplicationIcon()),
-		widget.NewIcon(theme.FileApplicationIcon()))
+	// Apps from the Registry
+	windows := wm.NewManager(a)
+	launcher := NewLauncher(windows)
+
+	// Wallpaper and Icons
+	desktop := NewDesktop(launcher)
+
+	// Start Menu
+	startMenu := NewStartMenu(launcher, w.Canvas())
+	var homeBtn *widget.Button
+	homeBtn = widget.NewButtonWithIcon("", theme.HomeIcon(), func() {
+		pos := a.Driver().AbsolutePositionForObject(homeBtn)
+		startMenu.Show(pos.Subtract(fyne.NewPos(320, 0)))
+	})
+
+	// Notifications
+	NewToaster(w, windows)
+	notificationPanel := NewNotificationPanel(w.Canvas())
+	var notificationBtn *widget.Button
+	notificationBtn = widget.NewButtonWithIcon("", theme.MailComposeIcon(), func() {
+		pos := a.Driver().AbsolutePositionForObject(notificationBtn)
+		notificationPanel.Show(pos)
+	})
+
+	// This Computer Panel
+	systemInfo := NewSystemInfo(a, windows, started)
 
 	// Right Layout
 	rightLayout := container.New(
 		vbox,
 		layout.NewSpacer(),
-		widget.NewButtonWithIcon("", theme.HomeIcon(), func() {}),
-		widget.NewButtonWithIcon("", theme.ComputerIcon(), func() {}),
+		homeBtn,
+		widget.NewButtonWithIcon("", theme.ComputerIcon(), func() { systemInfo.Show() }),
+		notificationBtn,
+		widget.NewSeparator(),
+		wm.NewTaskbar(windows), // Running Apps
 		layout.NewSpacer(),
-		widget.NewButtonWithIcon("", theme.LogoutIcon(), func() { w.Close() }))
+		widget.NewButtonWithIcon("", theme.LogoutIcon(), func() {
+			if err := session.Save(a, windows); err != nil {
+				fyne.LogError("Session not saved", err)
+			}
+			windows.CloseAll()
+			w.Close()
+		}))
 
 	// Combine all Layouts
-	c := container.New(
-		hbox,
-		leftBox,
-		layout.NewSpacer(),
-		rightLayout)
+	c := container.NewBorder(nil, nil, nil, rightLayout, desktop.Content())
 	w.SetContent(c)       // Add Layouts to Window
 	w.SetFullScreen(true) // Set Window to Full Screen
 	w.SetPadded(false)
+	if err := session.Load(a, windows); err != nil { // Reopen the last Session
+		fyne.LogError("Session not restored", err)
+	}
 	w.ShowAndRun() // Run Window
 }
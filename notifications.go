@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/notify"
+	"varos/wm"
+)
+
+// How long a toast stays on screen
+const toastTime = 4 * time.Second
+
+var levelIcons = map[notify.Level]fyne.Resource{
+	notify.LevelInfo:     theme.InfoIcon(),
+	notify.LevelSuccess:  theme.ConfirmIcon(),
+	notify.LevelError:    theme.ErrorIcon(),
+	notify.LevelProgress: theme.DownloadIcon(),
+}
+
+// Toaster shows posted notifications as toasts in the bottom right corner
+// of the focused app window, or of the desktop when no app has focus
+type Toaster struct {
+	shell  fyne.Window
+	wm     *wm.Manager
+	mu     sync.Mutex
+	active []toast
+}
+
+type toast struct {
+	popup  *widget.PopUp
+	canvas fyne.Canvas
+}
+
+func NewToaster(shell fyne.Window, m *wm.Manager) *Toaster {
+	t := &Toaster{shell: shell, wm: m}
+	notify.OnPosted(t.show)
+	return t
+}
+
+func (t *Toaster) show(n *notify.Notification) {
+	c := t.shell.Canvas()
+	if win := t.wm.Focused(); win != nil && !win.Minimized() {
+		c = win.Window.Canvas()
+	}
+	popup := widget.NewPopUp(notificationRow(n), c)
+	t.mu.Lock()
+	t.active = append(t.active, toast{popup: popup, canvas: c})
+	t.mu.Unlock()
+	t.layout(c)
+	hide := func() {
+		time.Sleep(toastTime)
+		popup.Hide()
+		t.remove(popup)
+		t.layout(c)
+	}
+	if n.Finished() {
+		go hide()
+	} else {
+		n.OnFinish(func() { go hide() })
+	}
+}
+
+// layout stacks the active toasts of c upwards from the corner
+func (t *Toaster) layout(c fyne.Canvas) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pad := theme.Padding() * 2
+	y := c.Size().Height - pad
+	for i := len(t.active) - 1; i >= 0; i-- {
+		if t.active[i].canvas != c {
+			continue
+		}
+		popup := t.active[i].popup
+		size := popup.MinSize().Max(fyne.NewSize(280, 0))
+		y -= size.Height
+		popup.Resize(size)
+		popup.ShowAtPosition(fyne.NewPos(c.Size().Width-size.Width-pad, y))
+		y -= pad
+	}
+}
+
+func (t *Toaster) remove(popup *widget.PopUp) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, active := range t.active {
+		if active.popup == popup {
+			t.active = append(t.active[:i], t.active[i+1:]...)
+			return
+		}
+	}
+}
+
+// notificationRow draws a notification with its icon, title, message and
+// progress bar
+func notificationRow(n *notify.Notification) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle(n.App+" - "+n.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	text := container.NewVBox(title, widget.NewLabel(n.Message))
+	if n.Progress != nil && !n.Finished() {
+		text.Add(widget.NewProgressBarWithData(n.Progress))
+	}
+	return container.NewBorder(nil, nil, widget.NewIcon(levelIcons[n.Level]), nil, text)
+}
+
+// NotificationPanel is the notification history with the do not disturb switch
+type NotificationPanel struct {
+	popup *widget.PopUp
+	list  *fyne.Container
+}
+
+func NewNotificationPanel(c fyne.Canvas) *NotificationPanel {
+	p := &NotificationPanel{list: container.NewVBox()}
+	dnd := widget.NewCheck("Do not disturb", notify.SetDoNotDisturb)
+	dnd.Checked = notify.DoNotDisturb()
+	clear := widget.NewButtonWithIcon("Clear", theme.DeleteIcon(), notify.Clear)
+	content := container.NewBorder(
+		container.NewHBox(widget.NewLabelWithStyle("Notifications", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		container.NewHBox(dnd, clear),
+		nil, nil,
+		container.NewVScroll(p.list))
+	p.popup = widget.NewPopUp(content, c)
+	notify.OnChanged(p.refresh)
+	p.refresh()
+	return p
+}
+
+// Show opens the panel with its top right corner at pos
+func (p *NotificationPanel) Show(pos fyne.Position) {
+	size := fyne.NewSize(340, 420)
+	p.popup.ShowAtPosition(pos.Subtract(fyne.NewPos(size.Width, 0)))
+	p.popup.Resize(size)
+}
+
+func (p *NotificationPanel) refresh() {
+	p.list.Objects = p.list.Objects[:0]
+	for _, n := range notify.History() {
+		row := container.NewBorder(nil, nil, nil,
+			widget.NewLabel(n.Time.Format("15:04")),
+			notificationRow(n))
+		p.list.Add(row)
+	}
+	if len(p.list.Objects) == 0 {
+		p.list.Add(widget.NewLabel("No notifications"))
+	}
+	p.list.Refresh()
+}
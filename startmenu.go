@@ -0,0 +1,236 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/recent"
+	"varos/registry"
+)
+
+// Number of recent apps and documents the start menu shows
+const startRecent = 5
+
+// startItem is a row of the start menu, rows without an action are headers
+type startItem struct {
+	label  string
+	icon   fyne.Resource
+	action func()
+}
+
+// StartMenu is the searchable app and document list behind the Home button
+//
+// Typing filters the list, Up and Down move the selection, Enter opens it
+// and Escape closes the menu.
+type StartMenu struct {
+	launcher *Launcher
+	popup    *widget.PopUp
+	search   *searchEntry
+	list     *widget.List
+	items    []startItem
+	selected int
+}
+
+func NewStartMenu(l *Launcher, c fyne.Canvas) *StartMenu {
+	s := &StartMenu{launcher: l, selected: -1}
+	s.search = newSearchEntry(s)
+	s.search.SetPlaceHolder("Search apps and documents")
+	s.search.OnChanged = s.filter
+	s.list = widget.NewList(
+		func() int {
+			return len(s.items)
+		},
+		func() fyne.CanvasObject {
+			return newStartRow(s)
+		},
+		func(id widget.ListItemID, o fyne.CanvasObject) {
+			row := o.(*startRow)
+			item := s.items[id]
+			row.id = id
+			row.icon.SetResource(item.icon)
+			if item.icon == nil {
+				row.icon.Hide()
+			} else {
+				row.icon.Show()
+			}
+			row.label.TextStyle = fyne.TextStyle{Bold: item.action == nil}
+			row.label.SetText(item.label)
+		},
+	)
+	// Taps are handled by the rows, the list does not select a row again
+	// once the keyboard has selected it
+	s.list.OnSelected = func(id widget.ListItemID) {
+		s.selected = id
+	}
+	content := container.NewBorder(s.search, nil, nil, nil, s.list)
+	s.popup = widget.NewPopUp(content, c)
+	return s
+}
+
+// Show opens the menu at pos with an empty search
+func (s *StartMenu) Show(pos fyne.Position) {
+	s.search.SetText("")
+	s.filter("")
+	s.popup.ShowAtPosition(pos)
+	s.popup.Resize(fyne.NewSize(320, 420))
+	fyne.CurrentApp().Driver().CanvasForObject(s.search).Focus(s.search)
+}
+
+// Hide closes the menu
+func (s *StartMenu) Hide() {
+	s.popup.Hide()
+}
+
+func (s *StartMenu) filter(text string) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		s.items = s.allItems()
+	} else {
+		s.items = s.matchingItems(text)
+	}
+	s.selected = -1
+	s.list.UnselectAll()
+	s.list.Refresh()
+	s.move(1)
+}
+
+func (s *StartMenu) allItems() []startItem {
+	items := make([]startItem, 0)
+	recentApps := make([]startItem, 0)
+	for _, id := range recent.Apps() {
+		if app, ok := registry.Get(id); ok && len(recentApps) < startRecent {
+			recentApps = append(recentApps, s.appItem(app))
+		}
+	}
+	if len(recentApps) > 0 {
+		items = append(items, startItem{label: "Recent"})
+		items = append(items, recentApps...)
+	}
+	for _, category := range registry.Categories() {
+		items = append(items, startItem{label: category})
+		for _, app := range registry.InCategory(category) {
+			items = append(items, s.appItem(app))
+		}
+	}
+	docs := recent.Documents()
+	if len(docs) > startRecent {
+		docs = docs[:startRecent]
+	}
+	if len(docs) > 0 {
+		items = append(items, startItem{label: "Recent Documents"})
+		for _, doc := range docs {
+			items = append(items, s.documentItem(doc))
+		}
+	}
+	return items
+}
+
+func (s *StartMenu) matchingItems(text string) []startItem {
+	items := make([]startItem, 0)
+	for _, app := range registry.Apps() {
+		if strings.Contains(strings.ToLower(app.Name), text) || strings.Contains(strings.ToLower(app.Category), text) {
+			items = append(items, s.appItem(app))
+		}
+	}
+	docs := make([]startItem, 0)
+	for _, doc := range recent.Documents() {
+		if strings.Contains(strings.ToLower(doc.Name()), text) {
+			docs = append(docs, s.documentItem(doc))
+		}
+	}
+	if len(docs) > 0 {
+		items = append(items, startItem{label: "Documents"})
+		items = append(items, docs...)
+	}
+	return items
+}
+
+func (s *StartMenu) appItem(app *registry.App) startItem {
+	return startItem{label: app.Name, icon: app.Icon, action: func() { s.launcher.Open(app) }}
+}
+
+func (s *StartMenu) documentItem(doc recent.Document) startItem {
+	icon := theme.FileIcon()
+	app, ok := registry.Get(doc.AppID)
+	if ok {
+		icon = app.Icon
+	}
+	return startItem{label: doc.Name(), icon: icon, action: func() {
+		if ok {
+			s.launcher.OpenFile(app, doc.URI)
+		}
+	}}
+}
+
+// move selects the next row with an action in the direction of step
+func (s *StartMenu) move(step int) {
+	for i := s.selected + step; i >= 0 && i < len(s.items); i += step {
+		if s.items[i].action != nil {
+			s.list.Select(i)
+			return
+		}
+	}
+}
+
+func (s *StartMenu) activate() {
+	if s.selected < 0 || s.selected >= len(s.items) || s.items[s.selected].action == nil {
+		return
+	}
+	action := s.items[s.selected].action
+	s.Hide()
+	action()
+}
+
+// startRow is a row of the start menu, tapping it opens its item
+type startRow struct {
+	widget.BaseWidget
+	menu  *StartMenu
+	id    widget.ListItemID
+	icon  *widget.Icon
+	label *widget.Label
+}
+
+func newStartRow(menu *StartMenu) *startRow {
+	r := &startRow{menu: menu, icon: widget.NewIcon(theme.FileApplicationIcon()), label: widget.NewLabel("Template Object")}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+func (r *startRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewHBox(r.icon, r.label))
+}
+
+func (r *startRow) Tapped(*fyne.PointEvent) {
+	r.menu.selected = r.id
+	r.menu.activate()
+}
+
+// searchEntry passes the navigation keys to the start menu
+type searchEntry struct {
+	widget.Entry
+	menu *StartMenu
+}
+
+func newSearchEntry(menu *StartMenu) *searchEntry {
+	e := &searchEntry{menu: menu}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+func (e *searchEntry) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyDown:
+		e.menu.move(1)
+	case fyne.KeyUp:
+		e.menu.move(-1)
+	case fyne.KeyReturn, fyne.KeyEnter:
+		e.menu.activate()
+	case fyne.KeyEscape:
+		e.menu.Hide()
+	default:
+		e.Entry.TypedKey(key)
+	}
+}
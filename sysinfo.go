@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"varos/wm"
+)
+
+// SystemInfo is the "This Computer" panel behind the Computer button
+type SystemInfo struct {
+	app     fyne.App
+	wm      *wm.Manager
+	started time.Time // Start of the VarOS session
+	window  fyne.Window
+
+	memory     binding.String
+	goroutines binding.String
+	uptime     binding.String
+	apps       binding.StringList
+
+	// running is the last snapshot of the app windows, taken on the UI
+	// thread whenever the window manager changes
+	mu      sync.Mutex
+	running map[string]*appWindows
+}
+
+// appWindows are the open windows of an app
+type appWindows struct {
+	windows int
+	objects int
+	since   time.Time
+}
+
+func NewSystemInfo(a fyne.App, m *wm.Manager, started time.Time) *SystemInfo {
+	s := &SystemInfo{
+		app:        a,
+		wm:         m,
+		started:    started,
+		memory:     binding.NewString(),
+		goroutines: binding.NewString(),
+		uptime:     binding.NewString(),
+		apps:       binding.NewStringList(),
+	}
+	m.OnChanged(s.snapshot)
+	return s
+}
+
+// Show opens the panel, or focuses it when it is already open
+func (s *SystemInfo) Show() {
+	if s.window != nil {
+		s.window.RequestFocus()
+		return
+	}
+	s.window = s.app.NewWindow("This Computer")
+	s.snapshot()
+	s.refresh()
+	done := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-done:
+				return
+			}
+		}
+	}()
+	s.window.SetOnClosed(func() {
+		close(done)
+		s.window = nil
+	})
+	s.window.SetContent(s.content())
+	s.window.Resize(fyne.NewSize(420, 380))
+	s.window.Show()
+}
+
+func (s *SystemInfo) content() fyne.CanvasObject {
+	system := container.New(
+		layout.NewFormLayout(),
+		widget.NewLabel("OS"), widget.NewLabel(runtime.GOOS+"/"+runtime.GOARCH),
+		widget.NewLabel("Go"), widget.NewLabel(runtime.Version()),
+		widget.NewLabel("CPUs"), widget.NewLabel(fmt.Sprint(runtime.NumCPU())),
+		widget.NewLabel("Memory"), widget.NewLabelWithData(s.memory),
+		widget.NewLabel("Goroutines"), widget.NewLabelWithData(s.goroutines),
+		widget.NewLabel("Uptime"), widget.NewLabelWithData(s.uptime),
+	)
+	apps := widget.NewListWithData(s.apps,
+		func() fyne.CanvasObject {
+			return widget.NewLabel("Template Object")
+		},
+		func(item binding.DataItem, row fyne.CanvasObject) {
+			row.(*widget.Label).Bind(item.(binding.String))
+		},
+	)
+	return container.NewBorder(
+		container.NewVBox(system, widget.NewSeparator(), widget.NewLabel("Running Apps")),
+		nil, nil, nil,
+		apps)
+}
+
+// snapshot counts the windows and canvas objects of each app. It walks the
+// window contents, so it only runs on the UI thread: from Show and from the
+// window manager, which tells its listeners from there
+func (s *SystemInfo) snapshot() {
+	apps := map[string]*appWindows{}
+	for _, win := range s.wm.Windows() {
+		a, ok := apps[win.App.Name]
+		if !ok {
+			a = &appWindows{since: win.Opened}
+			apps[win.App.Name] = a
+		}
+		a.windows++
+		if win.Window != nil {
+			a.objects += countObjects(win.Window.Content())
+		}
+		if win.Opened.Before(a.since) {
+			a.since = win.Opened
+		}
+	}
+	s.mu.Lock()
+	s.running = apps
+	s.mu.Unlock()
+}
+
+// refresh reads the runtime and the last snapshot of the apps into the
+// bindings, it runs every second off the UI thread
+func (s *SystemInfo) refresh() {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	s.memory.Set(fmt.Sprintf("%s in use, %s from OS, %d GCs", byteSize(mem.HeapAlloc), byteSize(mem.Sys), mem.NumGC))
+	s.goroutines.Set(fmt.Sprint(runtime.NumGoroutine()))
+	s.uptime.Set(time.Since(s.started).Round(time.Second).String())
+
+	s.mu.Lock()
+	lines := make([]string, 0, len(s.running))
+	for name, a := range s.running {
+		lines = append(lines, fmt.Sprintf("%s: %d window(s), %d canvas objects, up %s",
+			name, a.windows, a.objects, time.Since(a.since).Round(time.Second)))
+	}
+	s.mu.Unlock()
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		lines = append(lines, "No apps running")
+	}
+	s.apps.Set(lines)
+}
+
+// countObjects counts the canvas objects under o
+func countObjects(o fyne.CanvasObject) int {
+	if o == nil {
+		return 0
+	}
+	count := 1
+	if c, ok := o.(*fyne.Container); ok {
+		for _, child := range c.Objects {
+			count += countObjects(child)
+		}
+	}
+	return count
+}
+
+func byteSize(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"texteditor"
+
+	"fyne.io/fyne/v2/app"
+)
+
+func main() {
+	a := app.NewWithID("io.varos.texteditor")
+	w := a.NewWindow("Text Editor")
+	w.SetContent(texteditor.New(a, w))
+	w.ShowAndRun()
+}
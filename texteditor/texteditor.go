@@ -1,25 +1,52 @@
-package main
+package texteditor
 
 import (
+	_ "embed"
+	"io/ioutil"
 	"log"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/cmd/fyne_settings/settings"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
+	"varos/notify"
+	"varos/recent"
+	"varos/registry"
+	"varos/session"
+	"varos/ui"
 )
 
-func main() {
-	app := app.New()
-	w := app.NewWindow("Text Editor")
-	fileStatus := FileStatus{false, false, nil}
-	c, input := Content(&fileStatus)
-	w.SetContent(c)
-	w.SetMainMenu(makeMenu(app, w, input, &fileStatus))
+//go:embed manifest.json
+var manifest []byte
+
+func init() {
+	registry.MustRegisterWithFiles(manifest, New, OpenFile)
+}
+
+// New builds the text editor inside w and returns its content
+func New(a fyne.App, w fyne.Window) fyne.CanvasObject {
+	c, _, _ := newEditor(a, w)
+	return c
+}
+
+// OpenFile builds the text editor inside w with the file at uri loaded
+func OpenFile(a fyne.App, w fyne.Window, uri fyne.URI) fyne.CanvasObject {
+	c, input, fileStatus := newEditor(a, w)
+	reader, err := storage.Reader(uri)
+	if err != nil {
+		ui.ShowError(err, w)
+		return c
+	}
+	loadFile(reader, input, fileStatus, w)
+	return c
+}
+
+func newEditor(a fyne.App, w fyne.Window) (*fyne.Container, *widget.Entry, *FileStatus) {
+	fileStatus := &FileStatus{false, false, nil, nil}
+	c, input := Content(fileStatus)
+	w.SetMainMenu(makeMenu(a, w, input, fileStatus))
 	w.Resize(fyne.NewSize(540, 400))
 	w.SetOnClosed(func() {
 		if fileStatus.uri != nil {
@@ -27,13 +54,55 @@ func main() {
 		}
 	})
 	w.SetPadded(false)
-	w.ShowAndRun()
+	var state editorState
+	if session.Restore(w, &state) {
+		restoreEditor(state, input, fileStatus, w)
+	}
+	session.Track(w, func() interface{} { return saveEditor(input, fileStatus) })
+	return c, input, fileStatus
 }
 
 type FileStatus struct {
 	saved  bool
 	edited bool
 	uri    fyne.URIWriteCloser
+	file   fyne.URI // Last file opened or saved
+}
+
+// editorState is a text editor window as kept in the VarOS session
+type editorState struct {
+	URI    string
+	Text   string // Only kept with unsaved changes
+	Edited bool
+}
+
+func saveEditor(input *widget.Entry, fileStatus *FileStatus) interface{} {
+	state := editorState{Edited: fileStatus.edited}
+	if fileStatus.file != nil {
+		state.URI = fileStatus.file.String()
+	}
+	if fileStatus.edited {
+		state.Text = input.Text
+	}
+	return state
+}
+
+func restoreEditor(state editorState, input *widget.Entry, fileStatus *FileStatus, w fyne.Window) {
+	if state.URI != "" {
+		uri, err := storage.ParseURI(state.URI)
+		if err == nil {
+			*&fileStatus.file = uri
+			if !state.Edited {
+				if reader, err := storage.Reader(uri); err == nil {
+					loadFile(reader, input, fileStatus, w)
+				}
+			}
+		}
+	}
+	if state.Edited {
+		input.SetText(state.Text)
+		*&fileStatus.edited = true
+	}
 }
 
 func Content(fileStatus *FileStatus) (*fyne.Container, *widget.Entry) {
@@ -43,11 +112,11 @@ func Content(fileStatus *FileStatus) (*fyne.Container, *widget.Entry) {
 	input.OnChanged = func(_ string) {
 		*&fileStatus.edited = true
 	}
-	return container.New(layout.NewGridLayoutWithColumns(1), input), input
+	return container.NewBorder(ui.Toolbar(), nil, nil, nil, input), input
 }
 
 func NewWindowOpen(a fyne.App, w fyne.Window, fileStatus *FileStatus, material string) {
-	newFileStatus := FileStatus{false, false, nil}
+	newFileStatus := FileStatus{false, false, nil, nil}
 	neww := a.NewWindow("New File")
 	c, newInput := Content(&newFileStatus)
 	newInput.SetText(material)
@@ -70,24 +139,30 @@ func makeMenu(a fyne.App, w fyne.Window, input *widget.Entry, fileStatus *FileSt
 	openFile := fyne.NewMenuItem("Open", func() {
 		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 			if err != nil {
-				dialog.ShowError(err, w)
+				ui.ShowError(err, w)
 				return
 			}
 			if reader == nil {
 				log.Println("Cancelled")
 				return
 			}
+			loadFile(reader, input, fileStatus, w)
 		}, w)
 		fd.SetFilter(storage.NewExtensionFileFilter([]string{".txt"}))
 		fd.Show()
 	})
 	saveFile := fyne.NewMenuItem("Save", func() {
-		if fileStatus.uri != nil {
-			fileSaved(fileStatus.uri, input.Text, w)
+		if fileStatus.saved && fileStatus.file != nil {
+			writer, err := storage.Writer(fileStatus.file)
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			fileSaved(writer, input.Text, fileStatus, w)
 		} else {
 			dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
 				if err != nil {
-					dialog.ShowError(err, w)
+					ui.ShowError(err, w)
 					return
 				}
 				if writer == nil {
@@ -95,10 +170,9 @@ func makeMenu(a fyne.App, w fyne.Window, input *widget.Entry, fileStatus *FileSt
 					return
 				}
 				if writer != nil {
-					fileSaved(writer, input.Text, w)
-					*&fileStatus.uri = writer
-					*&fileStatus.saved = true
-					*&fileStatus.edited = false
+					recent.AddDocument("texteditor", writer.URI())
+					*&fileStatus.file = writer.URI()
+					fileSaved(writer, input.Text, fileStatus, w)
 				}
 			}, w)
 		}
@@ -118,15 +192,33 @@ func makeMenu(a fyne.App, w fyne.Window, input *widget.Entry, fileStatus *FileSt
 	)
 }
 
-func fileSaved(f fyne.URIWriteCloser, file string, w fyne.Window) {
-	//defer f.Close()
+func fileSaved(f fyne.URIWriteCloser, file string, fileStatus *FileStatus, w fyne.Window) {
 	_, err := f.Write([]byte(file))
+	// Close flushes the file, it is only saved once it succeeds
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	// The writer is spent, the next save opens the file again
+	*&fileStatus.uri = nil
 	if err != nil {
-		dialog.ShowError(err, w)
+		ui.ShowError(err, w)
+		return
 	}
-	//err = f.Close()
+	*&fileStatus.saved = true
+	*&fileStatus.edited = false
+	notify.Success("Text Editor", "Saved", f.URI().Name())
+}
+
+func loadFile(reader fyne.URIReadCloser, input *widget.Entry, fileStatus *FileStatus, w fyne.Window) {
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
 	if err != nil {
-		dialog.ShowError(err, w)
+		ui.ShowError(err, w)
+		return
 	}
-	log.Println("Saved to...", f.URI())
+	input.SetText(string(data))
+	*&fileStatus.edited = false
+	*&fileStatus.file = reader.URI()
+	recent.AddDocument("texteditor", reader.URI())
+	log.Println("Opened...", reader.URI())
 }
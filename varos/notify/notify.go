@@ -0,0 +1,190 @@
+// Package notify is the VarOS notification service.
+//
+// Apps post notifications with Info, Success, Error or Progress. The shell
+// shows them as toasts and keeps them in the notification history. With do
+// not disturb on they only go to the history. When no shell is listening,
+// as when an app runs on its own, they are sent as system notifications.
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+)
+
+// Level is the kind of a notification
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelSuccess
+	LevelError
+	LevelProgress
+)
+
+// HistoryLimit is how many notifications the history keeps
+const HistoryLimit = 50
+
+const dndKey = "varos.dnd" // Preference with the do not disturb switch
+
+// Notification is a message posted by an app
+type Notification struct {
+	ID       int
+	App      string
+	Title    string
+	Message  string
+	Level    Level
+	Time     time.Time
+	Progress binding.Float // Only for LevelProgress, from 0 to 1
+
+	mu       sync.Mutex
+	done     bool
+	onFinish []func()
+}
+
+var (
+	mu        sync.Mutex
+	nextID    int
+	history   []*Notification
+	posted    []func(*Notification)
+	changed   []func()
+	listening bool
+)
+
+// Post records a notification and shows it unless do not disturb is on
+func Post(app, title, message string, level Level) *Notification {
+	mu.Lock()
+	nextID++
+	n := &Notification{ID: nextID, App: app, Title: title, Message: message, Level: level, Time: time.Now()}
+	if level == LevelProgress {
+		n.Progress = binding.NewFloat()
+	}
+	history = append([]*Notification{n}, history...)
+	if len(history) > HistoryLimit {
+		history = history[:HistoryLimit]
+	}
+	onPosted := append([]func(*Notification){}, posted...)
+	shell := listening
+	mu.Unlock()
+
+	if !DoNotDisturb() {
+		if shell {
+			for _, f := range onPosted {
+				f(n)
+			}
+		} else if a := fyne.CurrentApp(); a != nil && level != LevelProgress {
+			a.SendNotification(fyne.NewNotification(app+": "+title, message))
+		}
+	}
+	notifyChanged()
+	return n
+}
+
+// Info posts an information notification
+func Info(app, title, message string) *Notification {
+	return Post(app, title, message, LevelInfo)
+}
+
+// Success posts a notification about something that completed
+func Success(app, title, message string) *Notification {
+	return Post(app, title, message, LevelSuccess)
+}
+
+// Error posts a notification about something that failed
+func Error(app, title string, err error) *Notification {
+	return Post(app, title, err.Error(), LevelError)
+}
+
+// Progress posts a notification with a progress bar, call SetProgress while
+// working and Finish at the end
+func Progress(app, title, message string) *Notification {
+	return Post(app, title, message, LevelProgress)
+}
+
+// SetProgress moves the progress bar, value goes from 0 to 1
+func (n *Notification) SetProgress(value float64) {
+	if n.Progress != nil {
+		n.Progress.Set(value)
+	}
+}
+
+// Finish ends a progress notification with a final message
+func (n *Notification) Finish(message string) {
+	n.mu.Lock()
+	n.done = true
+	n.Message = message
+	finish := append([]func(){}, n.onFinish...)
+	n.mu.Unlock()
+	n.SetProgress(1)
+	for _, f := range finish {
+		f()
+	}
+	notifyChanged()
+}
+
+// Finished reports if a progress notification has ended, other levels are always finished
+func (n *Notification) Finished() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.done || n.Level != LevelProgress
+}
+
+// OnFinish registers f to run when Finish is called
+func (n *Notification) OnFinish(f func()) {
+	n.mu.Lock()
+	n.onFinish = append(n.onFinish, f)
+	n.mu.Unlock()
+}
+
+// History returns the notifications, newest first
+func History() []*Notification {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]*Notification(nil), history...)
+}
+
+// Clear empties the history
+func Clear() {
+	mu.Lock()
+	history = nil
+	mu.Unlock()
+	notifyChanged()
+}
+
+// DoNotDisturb reports if toasts are held back
+func DoNotDisturb() bool {
+	a := fyne.CurrentApp()
+	return a != nil && a.Preferences().Bool(dndKey)
+}
+
+// SetDoNotDisturb turns do not disturb on or off
+func SetDoNotDisturb(on bool) {
+	fyne.CurrentApp().Preferences().SetBool(dndKey, on)
+	notifyChanged()
+}
+
+// OnPosted registers the shell to show each notification as it is posted
+func OnPosted(f func(*Notification)) {
+	mu.Lock()
+	posted = append(posted, f)
+	listening = true
+	mu.Unlock()
+}
+
+// OnChanged registers f to run when the history or do not disturb changes
+func OnChanged(f func()) {
+	mu.Lock()
+	changed = append(changed, f)
+	mu.Unlock()
+}
+
+func notifyChanged() {
+	mu.Lock()
+	list := append([]func(){}, changed...)
+	mu.Unlock()
+	for _, f := range list {
+		f()
+	}
+}
@@ -0,0 +1,91 @@
+// Package recent remembers the apps and documents used last.
+//
+// The lists live in the preferences of the current fyne app, newest first.
+package recent
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+// Limit is how many apps and documents are remembered
+const Limit = 10
+
+const (
+	appsKey      = "recent.apps"
+	documentsKey = "recent.documents"
+)
+
+// Document is a file opened by an app
+type Document struct {
+	AppID string
+	URI   fyne.URI
+}
+
+// Name returns the file name of the document
+func (d Document) Name() string {
+	return d.URI.Name()
+}
+
+// AddApp moves the app to the front of the recent apps
+func AddApp(appID string) {
+	push(appsKey, appID)
+}
+
+// Apps returns the ids of the recent apps
+func Apps() []string {
+	return load(appsKey)
+}
+
+// AddDocument moves uri, as opened by the app, to the front of the recent documents
+func AddDocument(appID string, uri fyne.URI) {
+	if uri == nil {
+		return
+	}
+	push(documentsKey, appID+"\t"+uri.String())
+}
+
+// Documents returns the recent documents
+func Documents() []Document {
+	docs := make([]Document, 0)
+	for _, line := range load(documentsKey) {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		uri, err := storage.ParseURI(parts[1])
+		if err != nil {
+			continue
+		}
+		docs = append(docs, Document{AppID: parts[0], URI: uri})
+	}
+	return docs
+}
+
+func load(key string) []string {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return nil
+	}
+	value := a.Preferences().String(key)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
+
+func push(key, entry string) {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return
+	}
+	list := []string{entry}
+	for _, old := range load(key) {
+		if old != entry && len(list) < Limit {
+			list = append(list, old)
+		}
+	}
+	a.Preferences().SetString(key, strings.Join(list, "\n"))
+}
@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// Manifest is the declarative part of an app, kept in its manifest.json
+//
+//	{
+//		"id": "calculator",
+//		"name": "Calculator",
+//		"icon": "fileApplication",
+//		"category": "Utilities",
+//		"singleInstance": false,
+//		"desktop": true,
+//		"extensions": [".txt"]
+//	}
+//
+// Icon is a fyne theme icon name, so the icon follows the current theme.
+type Manifest struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Icon           string   `json:"icon"`
+	Category       string   `json:"category"`
+	SingleInstance bool     `json:"singleInstance"`
+	Desktop        bool     `json:"desktop"`
+	Extensions     []string `json:"extensions"`
+}
+
+// ParseManifest decodes and checks a manifest.json
+func ParseManifest(data []byte) (Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("registry: bad manifest: %w", err)
+	}
+	if m.ID == "" || m.Name == "" {
+		return m, fmt.Errorf("registry: manifest needs an id and a name")
+	}
+	if m.Category == "" {
+		m.Category = "Other"
+	}
+	if m.Icon == "" {
+		m.Icon = string(theme.IconNameFileApplication)
+	}
+	return m, nil
+}
+
+// RegisterManifest registers the app described by data with its constructor
+func RegisterManifest(data []byte, constructor Constructor) error {
+	m, err := ParseManifest(data)
+	if err != nil {
+		return err
+	}
+	return Register(&App{
+		ID:             m.ID,
+		Name:           m.Name,
+		Icon:           themeIcon(m.Icon),
+		Category:       m.Category,
+		SingleInstance: m.SingleInstance,
+		Desktop:        m.Desktop,
+		Extensions:     m.Extensions,
+		New:            constructor,
+	})
+}
+
+// MustRegister is RegisterManifest for init functions, it panics on error
+func MustRegister(data []byte, constructor Constructor) {
+	if err := RegisterManifest(data, constructor); err != nil {
+		panic(err)
+	}
+}
+
+// MustRegisterWithFiles is MustRegister for apps that also open files
+func MustRegisterWithFiles(data []byte, constructor Constructor, open FileConstructor) {
+	MustRegister(data, constructor)
+	m, _ := ParseManifest(data)
+	if err := SetFileHandler(m.ID, open); err != nil {
+		panic(err)
+	}
+}
+
+// themeIcon looks its icon up in the current theme every time it is drawn
+type themeIcon fyne.ThemeIconName
+
+func (t themeIcon) resource() fyne.Resource {
+	if current := fyne.CurrentApp(); current != nil && current.Settings().Theme() != nil {
+		if icon := current.Settings().Theme().Icon(fyne.ThemeIconName(t)); icon != nil {
+			return icon
+		}
+	}
+	return theme.FileApplicationIcon()
+}
+
+func (t themeIcon) Name() string {
+	return t.resource().Name()
+}
+
+func (t themeIcon) Content() []byte {
+	return t.resource().Content()
+}
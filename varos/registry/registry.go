@@ -0,0 +1,114 @@
+// Package registry keeps the list of applications installed in VarOS.
+//
+// Apps register themselves from an init function, normally with
+// MustRegister and an embedded manifest.json, and the desktop builds its
+// launcher, Home menu and icon column from Apps.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+)
+
+// Constructor builds an app inside w and returns its content
+type Constructor func(a fyne.App, w fyne.Window) fyne.CanvasObject
+
+// FileConstructor builds an app inside w with the file at uri already open
+type FileConstructor func(a fyne.App, w fyne.Window, uri fyne.URI) fyne.CanvasObject
+
+// App is an installed application
+type App struct {
+	ID             string
+	Name           string
+	Icon           fyne.Resource
+	Category       string
+	SingleInstance bool
+	Desktop        bool     // Shown in the desktop icon column
+	Extensions     []string // File types the app opens, like ".txt"
+	New            Constructor
+	OpenFile       FileConstructor // Optional
+}
+
+var (
+	mu   sync.RWMutex
+	apps = map[string]*App{}
+)
+
+// Register adds app to the registry, it fails if the ID is taken
+func Register(app *App) error {
+	if app.ID == "" {
+		return fmt.Errorf("registry: app %q has no id", app.Name)
+	}
+	if app.New == nil {
+		return fmt.Errorf("registry: app %q has no constructor", app.ID)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := apps[app.ID]; ok {
+		return fmt.Errorf("registry: app %q registered twice", app.ID)
+	}
+	apps[app.ID] = app
+	return nil
+}
+
+// SetFileHandler lets the app with id be started with a file
+func SetFileHandler(id string, open FileConstructor) error {
+	mu.Lock()
+	defer mu.Unlock()
+	app, ok := apps[id]
+	if !ok {
+		return fmt.Errorf("registry: app %q is not registered", id)
+	}
+	app.OpenFile = open
+	return nil
+}
+
+// Get returns the app registered under id
+func Get(id string) (*App, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	app, ok := apps[id]
+	return app, ok
+}
+
+// Apps returns every registered app sorted by name
+func Apps() []*App {
+	mu.RLock()
+	list := make([]*App, 0, len(apps))
+	for _, app := range apps {
+		list = append(list, app)
+	}
+	mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Categories returns the sorted category names in use
+func Categories() []string {
+	seen := map[string]bool{}
+	categories := make([]string, 0)
+	for _, app := range Apps() {
+		if !seen[app.Category] {
+			seen[app.Category] = true
+			categories = append(categories, app.Category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// InCategory returns the apps of one category sorted by name
+func InCategory(category string) []*App {
+	list := make([]*App, 0)
+	for _, app := range Apps() {
+		if app.Category == category {
+			list = append(list, app)
+		}
+	}
+	return list
+}
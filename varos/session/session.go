@@ -0,0 +1,159 @@
+// Package session saves the open app windows at logout and reopens them
+// at the next launch.
+//
+// Apps call Track from their constructor to say how a window saves its
+// state, and Restore to read the state back when the window is reopened.
+package session
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"varos/registry"
+	"varos/wm"
+)
+
+// FileName is the session file in the storage of the VarOS app
+const FileName = "session.json"
+
+// Session is the saved desktop
+type Session struct {
+	Windows []Window `json:"windows"`
+}
+
+// Window is a saved app window
+type Window struct {
+	App       string          `json:"app"`
+	Width     float32         `json:"width"`
+	Height    float32         `json:"height"`
+	Minimized bool            `json:"minimized"`
+	State     json.RawMessage `json:"state,omitempty"`
+}
+
+var (
+	mu        sync.Mutex
+	savers    = map[fyne.Window]func() interface{}{}
+	restoring = map[fyne.Window]json.RawMessage{}
+)
+
+// Track registers save as the way w stores its state, the value is encoded as JSON
+func Track(w fyne.Window, save func() interface{}) {
+	mu.Lock()
+	savers[w] = save
+	mu.Unlock()
+}
+
+// Restore decodes the saved state of w into v, it reports false when w is
+// not being restored
+func Restore(w fyne.Window, v interface{}) bool {
+	mu.Lock()
+	state, ok := restoring[w]
+	mu.Unlock()
+	if !ok || len(state) == 0 {
+		return false
+	}
+	if err := json.Unmarshal(state, v); err != nil {
+		fyne.LogError("session: bad state for "+w.Title(), err)
+		return false
+	}
+	return true
+}
+
+// Capture returns the session of every window open in m
+func Capture(m *wm.Manager) (Session, error) {
+	var s Session
+	open := map[fyne.Window]bool{}
+	for _, win := range m.Windows() {
+		open[win.Window] = true
+	}
+	mu.Lock()
+	for w := range savers {
+		if !open[w] {
+			delete(savers, w)
+		}
+	}
+	mu.Unlock()
+	for _, win := range m.Windows() {
+		size := win.Window.Canvas().Size()
+		saved := Window{App: win.App.ID, Width: size.Width, Height: size.Height, Minimized: win.Minimized()}
+		mu.Lock()
+		save, ok := savers[win.Window]
+		mu.Unlock()
+		if ok {
+			state, err := json.Marshal(save())
+			if err != nil {
+				return s, err
+			}
+			saved.State = state
+		}
+		s.Windows = append(s.Windows, saved)
+	}
+	return s, nil
+}
+
+// Save writes the session of m to the storage of a
+func Save(a fyne.App, m *wm.Manager) error {
+	s, err := Capture(m)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	writer, err := a.Storage().Save(FileName)
+	if err != nil {
+		writer, err = a.Storage().Create(FileName)
+		if err != nil {
+			return err
+		}
+	}
+	defer writer.Close()
+	_, err = writer.Write(data)
+	return err
+}
+
+// Load reopens the windows saved in the storage of a through m and removes
+// the session file, it does nothing when there is no saved session
+func Load(a fyne.App, m *wm.Manager) error {
+	reader, err := a.Storage().Open(FileName)
+	if err != nil {
+		return nil
+	}
+	data, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, saved := range s.Windows {
+		app, ok := registry.Get(saved.App)
+		if !ok {
+			continue
+		}
+		saved := saved
+		win := m.OpenWith(app, func(w fyne.Window) fyne.CanvasObject {
+			mu.Lock()
+			restoring[w] = saved.State
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				delete(restoring, w)
+				mu.Unlock()
+			}()
+			return app.New(a, w)
+		})
+		if saved.Width > 0 && saved.Height > 0 {
+			win.Window.Resize(fyne.NewSize(saved.Width, saved.Height))
+		}
+		if saved.Minimized {
+			win.Minimize()
+		}
+	}
+	return a.Storage().Remove(FileName)
+}
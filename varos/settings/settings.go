@@ -0,0 +1,105 @@
+// Package settings is the VarOS settings app, where the theme of every app
+// and the desktop wallpaper are chosen.
+package settings
+
+import (
+	_ "embed"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/registry"
+	"varos/themes"
+	"varos/ui"
+)
+
+//go:embed manifest.json
+var manifest []byte
+
+func init() {
+	registry.MustRegister(manifest, New)
+}
+
+// New builds the settings app inside w and returns its content
+func New(a fyne.App, w fyne.Window) fyne.CanvasObject {
+	w.Resize(fyne.NewSize(360, 400))
+	return container.NewAppTabs(
+		container.NewTabItemWithIcon("Theme", theme.ColorPaletteIcon(), themeTab(w)),
+		container.NewTabItemWithIcon("Wallpaper", theme.MediaPhotoIcon(), wallpaperTab(w)),
+	)
+}
+
+func themeTab(w fyne.Window) fyne.CanvasObject {
+	installed := themes.Installed()
+	list := widget.NewList(
+		func() int {
+			return len(installed)
+		},
+		func() fyne.CanvasObject {
+			return container.NewHBox(themeSwatch(themes.Current()), widget.NewLabel("Template Object"))
+		},
+		func(id widget.ListItemID, row fyne.CanvasObject) {
+			row.(*fyne.Container).Objects[0] = themeSwatch(installed[id])
+			row.(*fyne.Container).Objects[1].(*widget.Label).SetText(installed[id].Name())
+			row.Refresh()
+		},
+	)
+	selectCurrent := func() {
+		for id, t := range installed {
+			if t.Name() == themes.Current().Name() {
+				list.Select(id)
+			}
+		}
+	}
+	list.OnSelected = func(id widget.ListItemID) {
+		if installed[id].Name() != themes.Current().Name() {
+			themes.Apply(installed[id])
+		}
+	}
+	selectCurrent()
+
+	addBtn := widget.NewButtonWithIcon("Add Theme File", theme.ContentAddIcon(), func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			t, err := themes.Install(reader.URI().Path())
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			themes.Apply(t)
+			installed = themes.Installed()
+			list.Refresh()
+			selectCurrent()
+		}, w)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".yaml", ".yml"}))
+		fd.Show()
+	})
+
+	return container.NewBorder(
+		container.NewVBox(widget.NewLabelWithStyle("Theme", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), widget.NewSeparator()),
+		container.NewHBox(addBtn),
+		nil, nil,
+		list)
+}
+
+// themeSwatch shows the background, button and primary colours of t
+func themeSwatch(t *themes.Theme) fyne.CanvasObject {
+	swatch := container.NewHBox()
+	for _, name := range []fyne.ThemeColorName{theme.ColorNameBackground, theme.ColorNameButton, theme.ColorNamePrimary} {
+		r := canvas.NewRectangle(t.Color(name, t.Variant()))
+		r.SetMinSize(fyne.NewSize(14, 24))
+		swatch.Add(r)
+	}
+	return swatch
+}
@@ -0,0 +1,124 @@
+package settings
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"varos/ui"
+	"varos/wallpaper"
+)
+
+var wallpaperKinds = map[string]wallpaper.Kind{
+	"Solid Colour": wallpaper.Color,
+	"Gradient":     wallpaper.Gradient,
+	"Image":        wallpaper.Image,
+}
+
+var wallpaperFills = map[string]string{
+	"Stretch":       "stretch",
+	"Contain":       "contain",
+	"Original Size": "original",
+}
+
+func wallpaperTab(w fyne.Window) fyne.CanvasObject {
+	wp := wallpaper.Get()
+	preview := container.NewMax(wp.Object())
+	apply := func() {
+		wallpaper.Set(wp)
+		preview.Objects = []fyne.CanvasObject{wp.Object()}
+		preview.Refresh()
+	}
+
+	colorBtn := widget.NewButton("Colour", func() {
+		dialog.NewColorPicker("Colour", "Wallpaper colour", func(c color.Color) {
+			wp.Color = hexColor(c)
+			apply()
+		}, w).Show()
+	})
+	endBtn := widget.NewButton("End Colour", func() {
+		dialog.NewColorPicker("End Colour", "Gradient end colour", func(c color.Color) {
+			wp.End = hexColor(c)
+			apply()
+		}, w).Show()
+	})
+	angle := widget.NewSlider(0, 360)
+	angle.Step = 15
+	angle.Value = wp.Angle
+	angle.OnChanged = func(v float64) {
+		wp.Angle = v
+		apply()
+	}
+	imageBtn := widget.NewButton("Choose Image", func() {
+		fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				ui.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			wp.Image = reader.URI().Path()
+			apply()
+		}, w)
+		fd.SetFilter(storage.NewExtensionFileFilter([]string{".jpg", ".jpeg", ".png"}))
+		fd.Show()
+	})
+	fill := widget.NewSelect([]string{"Stretch", "Contain", "Original Size"}, func(name string) {
+		wp.Fill = wallpaperFills[name]
+		apply()
+	})
+	for name, value := range wallpaperFills {
+		if value == wp.Fill {
+			fill.Selected = name
+		}
+	}
+
+	gradientRow := container.NewHBox(endBtn, widget.NewLabel("Angle"))
+	imageRow := container.NewHBox(imageBtn, fill)
+	showRows := func() {
+		colorBtn.Show()
+		gradientRow.Hide()
+		angle.Hide()
+		imageRow.Hide()
+		switch wp.Kind {
+		case wallpaper.Gradient:
+			gradientRow.Show()
+			angle.Show()
+		case wallpaper.Image:
+			colorBtn.Hide()
+			imageRow.Show()
+		}
+	}
+	kind := widget.NewRadioGroup([]string{"Solid Colour", "Gradient", "Image"}, func(name string) {
+		if name == "" {
+			return
+		}
+		wp.Kind = wallpaperKinds[name]
+		showRows()
+		apply()
+	})
+	kind.Horizontal = true
+	for name, value := range wallpaperKinds {
+		if value == wp.Kind {
+			kind.Selected = name
+		}
+	}
+	showRows()
+
+	return container.NewBorder(
+		container.NewVBox(kind, container.NewHBox(colorBtn, layout.NewSpacer()), gradientRow, angle, imageRow, widget.NewSeparator()),
+		nil, nil, nil,
+		preview)
+}
+
+func hexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
@@ -0,0 +1,139 @@
+package themes
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+const (
+	currentKey = "varos.theme"  // Preference with the name of the chosen theme
+	filesKey   = "varos.themes" // Preference with the installed theme files
+)
+
+//go:embed builtin
+var builtinFiles embed.FS
+
+// builtinOrder is the order the built in themes are listed in, the first
+// one is used until the user picks another
+var builtinOrder = []string{
+	"system.json",
+	"light.json",
+	"dark.json",
+	"ocean.json",
+	"solarized.yaml",
+	"highcontrast.json",
+}
+
+var (
+	builtin []*Theme
+	current *Theme
+)
+
+func init() {
+	fsys, _ := fs.Sub(builtinFiles, "builtin")
+	for _, name := range builtinOrder {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			panic(err)
+		}
+		t, err := Parse(data, path.Ext(name), fsys)
+		if err != nil {
+			panic("themes: builtin " + name + ": " + err.Error())
+		}
+		builtin = append(builtin, t)
+	}
+}
+
+// Builtin returns the themes shipped with VarOS
+func Builtin() []*Theme {
+	return append([]*Theme(nil), builtin...)
+}
+
+// Installed returns the built in themes followed by the theme files the
+// user added, files that no longer load are skipped
+func Installed() []*Theme {
+	list := Builtin()
+	for _, p := range installedFiles() {
+		if t, err := LoadFile(p); err == nil {
+			list = append(list, t)
+		} else {
+			fyne.LogError("Theme not loaded", err)
+		}
+	}
+	return list
+}
+
+// Get returns the installed theme called name
+func Get(name string) (*Theme, bool) {
+	for _, t := range Installed() {
+		if t.Name() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Install adds the theme file at p to the installed themes
+func Install(p string) (*Theme, error) {
+	t, err := LoadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	files := installedFiles()
+	for _, old := range files {
+		if old == p {
+			return t, nil
+		}
+	}
+	files = append(files, p)
+	fyne.CurrentApp().Preferences().SetString(filesKey, strings.Join(files, "\n"))
+	return t, nil
+}
+
+// Current returns the theme in use
+func Current() *Theme {
+	if current == nil {
+		current = builtin[0]
+	}
+	return current
+}
+
+// Apply makes t the theme of every window and remembers the choice
+func Apply(t *Theme) {
+	current = t
+	a := fyne.CurrentApp()
+	a.Preferences().SetString(currentKey, t.Name())
+	a.Settings().SetTheme(t)
+}
+
+// ToggleVariant switches between the built in light and dark themes
+func ToggleVariant() {
+	name := "Dark"
+	if Current().Variant() == theme.VariantDark {
+		name = "Light"
+	}
+	if t, ok := Get(name); ok {
+		Apply(t)
+	}
+}
+
+// Restore applies the theme chosen last time, if there is one
+func Restore() {
+	name := fyne.CurrentApp().Preferences().String(currentKey)
+	if t, ok := Get(name); ok && name != "" {
+		Apply(t)
+	}
+}
+
+func installedFiles() []string {
+	value := fyne.CurrentApp().Preferences().String(filesKey)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
@@ -0,0 +1,226 @@
+// Package themes is the VarOS theme engine.
+//
+// A theme is a JSON or YAML file naming colours, fonts, sizes and icon
+// overrides, anything it leaves out comes from the fyne default theme:
+//
+//	{
+//		"name": "Ocean",
+//		"variant": "dark",
+//		"colors": {"background": "#0b1e2d", "primary": "#2fa4e7"},
+//		"fonts": {"regular": "fonts/Inter.ttf", "monospace": "fonts/Mono.ttf"},
+//		"sizes": {"padding": 5, "text": 14},
+//		"icons": {"home": "icons/home.svg"}
+//	}
+//
+// Colour, size and icon keys are the fyne theme names, font keys are
+// regular, bold, italic, boldItalic and monospace. Font and icon paths are
+// relative to the theme file.
+package themes
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"gopkg.in/yaml.v2"
+)
+
+// File is the theme file format
+type File struct {
+	Name    string             `json:"name" yaml:"name"`
+	Variant string             `json:"variant" yaml:"variant"` // "light", "dark" or empty to follow the system
+	Colors  map[string]string  `json:"colors" yaml:"colors"`
+	Fonts   map[string]string  `json:"fonts" yaml:"fonts"`
+	Sizes   map[string]float32 `json:"sizes" yaml:"sizes"`
+	Icons   map[string]string  `json:"icons" yaml:"icons"`
+}
+
+// Theme is a loaded VarOS theme, it implements fyne.Theme
+type Theme struct {
+	name    string
+	path    string // Theme file, empty for built in themes
+	variant fyne.ThemeVariant
+	forced  bool // Variant is set by the theme, not the system
+	colors  map[fyne.ThemeColorName]color.Color
+	fonts   map[string]fyne.Resource
+	sizes   map[fyne.ThemeSizeName]float32
+	icons   map[fyne.ThemeIconName]fyne.Resource
+}
+
+var _ fyne.Theme = (*Theme)(nil)
+
+// LoadFile reads the theme file at p, JSON or YAML by its extension
+func LoadFile(p string) (*Theme, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Parse(data, filepath.Ext(p), os.DirFS(filepath.Dir(p)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+	}
+	t.path = p
+	return t, nil
+}
+
+// Parse decodes a theme file, ext picks the format and fsys holds the fonts and icons
+func Parse(data []byte, ext string, fsys fs.FS) (*Theme, error) {
+	var f File
+	var err error
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	default:
+		err = json.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.Name == "" {
+		return nil, fmt.Errorf("theme has no name")
+	}
+
+	t := &Theme{
+		name:   f.Name,
+		colors: map[fyne.ThemeColorName]color.Color{},
+		fonts:  map[string]fyne.Resource{},
+		sizes:  map[fyne.ThemeSizeName]float32{},
+		icons:  map[fyne.ThemeIconName]fyne.Resource{},
+	}
+	switch f.Variant {
+	case "light":
+		t.variant, t.forced = theme.VariantLight, true
+	case "dark":
+		t.variant, t.forced = theme.VariantDark, true
+	case "":
+	default:
+		return nil, fmt.Errorf("unknown variant %q", f.Variant)
+	}
+	for name, hex := range f.Colors {
+		c, err := ParseColor(hex)
+		if err != nil {
+			return nil, fmt.Errorf("color %s: %w", name, err)
+		}
+		t.colors[fyne.ThemeColorName(name)] = c
+	}
+	for name, size := range f.Sizes {
+		t.sizes[fyne.ThemeSizeName(name)] = size
+	}
+	for style, file := range f.Fonts {
+		res, err := loadResource(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("font %s: %w", style, err)
+		}
+		t.fonts[style] = res
+	}
+	for name, file := range f.Icons {
+		res, err := loadResource(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("icon %s: %w", name, err)
+		}
+		t.icons[fyne.ThemeIconName(name)] = res
+	}
+	return t, nil
+}
+
+// ParseColor reads #rgb, #rrggbb or #rrggbbaa
+func ParseColor(hex string) (color.Color, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("%q is not a hex colour", hex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a hex colour", hex)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+func loadResource(fsys fs.FS, file string) (fyne.Resource, error) {
+	data, err := fs.ReadFile(fsys, path.Clean(filepath.ToSlash(file)))
+	if err != nil {
+		return nil, err
+	}
+	return fyne.NewStaticResource(path.Base(file), data), nil
+}
+
+// Name returns the theme name
+func (t *Theme) Name() string {
+	return t.name
+}
+
+// Path returns the theme file, it is empty for built in themes
+func (t *Theme) Path() string {
+	return t.path
+}
+
+// Variant returns the variant the theme is drawn in
+func (t *Theme) Variant() fyne.ThemeVariant {
+	if t.forced {
+		return t.variant
+	}
+	if a := fyne.CurrentApp(); a != nil {
+		return a.Settings().ThemeVariant()
+	}
+	return theme.VariantDark
+}
+
+// Color returns the theme colour, or the default one for the variant
+func (t *Theme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if c, ok := t.colors[name]; ok {
+		return c
+	}
+	if t.forced {
+		variant = t.variant
+	}
+	return theme.DefaultTheme().Color(name, variant)
+}
+
+// Font returns the font for style
+func (t *Theme) Font(style fyne.TextStyle) fyne.Resource {
+	key := "regular"
+	switch {
+	case style.Monospace:
+		key = "monospace"
+	case style.Bold && style.Italic:
+		key = "boldItalic"
+	case style.Bold:
+		key = "bold"
+	case style.Italic:
+		key = "italic"
+	}
+	if res, ok := t.fonts[key]; ok {
+		return res
+	}
+	return theme.DefaultTheme().Font(style)
+}
+
+// Icon returns the icon override for name, or the default icon
+func (t *Theme) Icon(name fyne.ThemeIconName) fyne.Resource {
+	if res, ok := t.icons[name]; ok {
+		return res
+	}
+	return theme.DefaultTheme().Icon(name)
+}
+
+// Size returns the size for name
+func (t *Theme) Size(name fyne.ThemeSizeName) float32 {
+	if size, ok := t.sizes[name]; ok {
+		return size
+	}
+	return theme.DefaultTheme().Size(name)
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"log"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// ShowError logs err and shows it in a dialog over w
+func ShowError(err error, w fyne.Window) {
+	if err == nil {
+		return
+	}
+	log.Println("Error:", err)
+	dialog.ShowError(err, w)
+}
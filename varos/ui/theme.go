@@ -0,0 +1,13 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"varos/themes"
+)
+
+// NewThemeButton returns a button flipping between the light and dark theme,
+// the choice is shared by every app
+func NewThemeButton() *widget.Button {
+	return widget.NewButtonWithIcon("", theme.ColorPaletteIcon(), themes.ToggleVariant)
+}
@@ -0,0 +1,16 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+)
+
+// Toolbar returns the top bar of an app, items on the left and the theme
+// switcher on the right
+func Toolbar(items ...fyne.CanvasObject) *fyne.Container {
+	bar := container.NewHBox(items...)
+	bar.Add(layout.NewSpacer())
+	bar.Add(NewThemeButton())
+	return bar
+}
@@ -0,0 +1,36 @@
+// Package ui holds the widgets and helpers shared by the VarOS apps, so
+// that they look and behave the same.
+package ui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/layout"
+)
+
+// BoxLayout returns the box layout for "H" (horizontal) or "V" (vertical)
+func BoxLayout(box string) fyne.Layout {
+	layouts := map[string]fyne.Layout{
+		"H": layout.NewHBoxLayout(),
+		"V": layout.NewVBoxLayout(),
+	}
+	return layouts[box]
+}
+
+// GridLayout returns a grid of n columns for "C", n rows for "R" or n
+// squares for ""
+func GridLayout(box string, n int) fyne.Layout {
+	layouts := map[string]fyne.Layout{
+		"":  layout.NewGridLayout(n),
+		"C": layout.NewGridLayoutWithColumns(n),
+		"R": layout.NewGridLayoutWithRows(n),
+	}
+	return layouts[box]
+}
+
+// Text returns a canvas text in the given RGBA colour
+func Text(str string, r uint8, g uint8, b uint8, a uint8) *canvas.Text {
+	return canvas.NewText(str, color.RGBA{R: r, G: g, B: b, A: a})
+}
@@ -0,0 +1,114 @@
+// Package wallpaper keeps the desktop background of VarOS.
+//
+// Apps such as the Gallery call Set, the shell listens with OnChanged and
+// draws Object behind the desktop icons.
+package wallpaper
+
+import (
+	"encoding/json"
+	"image/color"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"varos/themes"
+)
+
+// Kind is what the wallpaper is made of
+type Kind string
+
+const (
+	Color    Kind = "color"
+	Gradient Kind = "gradient"
+	Image    Kind = "image"
+)
+
+// Fill modes of an image wallpaper, named after canvas.ImageFill
+var Fills = map[string]canvas.ImageFill{
+	"stretch":  canvas.ImageFillStretch,
+	"contain":  canvas.ImageFillContain,
+	"original": canvas.ImageFillOriginal,
+}
+
+const prefKey = "varos.wallpaper"
+
+// Wallpaper is the desktop background, colours are hex strings like "#336699"
+type Wallpaper struct {
+	Kind  Kind    `json:"kind"`
+	Color string  `json:"color"`
+	End   string  `json:"end"`   // Gradient end colour
+	Angle float64 `json:"angle"` // Gradient angle in degrees
+	Image string  `json:"image"` // Image file path
+	Fill  string  `json:"fill"`  // Key of Fills
+}
+
+// Default is used until a wallpaper is set
+var Default = Wallpaper{Kind: Gradient, Color: "#1e3c72", End: "#2a5298", Angle: 135}
+
+var (
+	mu        sync.Mutex
+	listeners []func(Wallpaper)
+)
+
+// Get returns the current wallpaper
+func Get() Wallpaper {
+	a := fyne.CurrentApp()
+	if a == nil {
+		return Default
+	}
+	var wp Wallpaper
+	if err := json.Unmarshal([]byte(a.Preferences().String(prefKey)), &wp); err != nil || wp.Kind == "" {
+		return Default
+	}
+	return wp
+}
+
+// Set stores wp and tells the listeners
+func Set(wp Wallpaper) {
+	data, err := json.Marshal(wp)
+	if err != nil {
+		fyne.LogError("Wallpaper not saved", err)
+		return
+	}
+	fyne.CurrentApp().Preferences().SetString(prefKey, string(data))
+	mu.Lock()
+	list := append([]func(Wallpaper){}, listeners...)
+	mu.Unlock()
+	for _, f := range list {
+		f(wp)
+	}
+}
+
+// SetImage makes the image at path the wallpaper with the named fill mode
+func SetImage(path string, fill string) {
+	Set(Wallpaper{Kind: Image, Image: path, Fill: fill})
+}
+
+// OnChanged registers f to run when the wallpaper changes
+func OnChanged(f func(Wallpaper)) {
+	mu.Lock()
+	listeners = append(listeners, f)
+	mu.Unlock()
+}
+
+// Object returns the canvas object drawing wp
+func (wp Wallpaper) Object() fyne.CanvasObject {
+	switch wp.Kind {
+	case Image:
+		img := canvas.NewImageFromFile(wp.Image)
+		img.FillMode = Fills[wp.Fill]
+		return img
+	case Gradient:
+		return canvas.NewLinearGradient(parse(wp.Color), parse(wp.End), wp.Angle)
+	default:
+		return canvas.NewRectangle(parse(wp.Color))
+	}
+}
+
+func parse(hex string) color.Color {
+	c, err := themes.ParseColor(hex)
+	if err != nil {
+		return color.Black
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package wm
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// NewTaskbar returns a column with a button for every open window
+//
+// Tapping a button focuses its window, or minimizes it when it already has
+// focus. The secondary tap opens a menu with the other actions.
+func NewTaskbar(m *Manager) fyne.CanvasObject {
+	bar := container.NewVBox()
+	m.OnChanged(func() {
+		bar.Objects = bar.Objects[:0]
+		for _, win := range m.Windows() {
+			bar.Add(newTaskButton(win))
+		}
+		bar.Refresh()
+	})
+	return bar
+}
+
+type taskButton struct {
+	widget.Button
+	win *Window
+}
+
+func newTaskButton(win *Window) *taskButton {
+	b := &taskButton{win: win}
+	b.ExtendBaseWidget(b)
+	b.Icon = win.App.Icon
+	b.OnTapped = func() {
+		if win.Focused() && !win.Minimized() {
+			win.Minimize()
+		} else {
+			win.Focus()
+		}
+	}
+	switch {
+	case win.Minimized():
+		b.Importance = widget.LowImportance
+	case win.Focused():
+		b.Importance = widget.HighImportance
+	}
+	return b
+}
+
+// TappedSecondary shows the window menu
+func (b *taskButton) TappedSecondary(e *fyne.PointEvent) {
+	state := fyne.NewMenuItem("Minimize", b.win.Minimize)
+	if b.win.Minimized() {
+		state = fyne.NewMenuItem("Restore", b.win.Restore)
+	}
+	menu := fyne.NewMenu(b.win.Title(),
+		fyne.NewMenuItem(b.win.Title(), b.win.Focus),
+		fyne.NewMenuItemSeparator(),
+		state,
+		fyne.NewMenuItem("Close", b.win.Close))
+	c := fyne.CurrentApp().Driver().CanvasForObject(b)
+	widget.ShowPopUpMenuAtPosition(menu, c, e.AbsolutePosition)
+}
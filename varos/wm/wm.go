@@ -0,0 +1,239 @@
+// Package wm is the VarOS window manager.
+//
+// It tracks the windows of running apps and lets the shell and the apps
+// themselves focus, minimize, restore and close them.
+package wm
+
+import (
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"varos/registry"
+)
+
+// Window is an app window tracked by the manager
+type Window struct {
+	ID     int
+	App    *registry.App
+	Window fyne.Window
+	Opened time.Time
+
+	manager   *Manager
+	minimized bool
+}
+
+// Manager keeps the open app windows in the order they were opened
+type Manager struct {
+	app fyne.App
+
+	mu        sync.Mutex
+	windows   []*Window
+	focused   *Window
+	nextID    int
+	listeners []func()
+}
+
+var current *Manager
+
+// NewManager creates the window manager of the shell, apps reach it with Current
+func NewManager(a fyne.App) *Manager {
+	current = &Manager{app: a}
+	return current
+}
+
+// Current returns the running window manager, nil when an app runs on its own
+func Current() *Manager {
+	return current
+}
+
+// For returns the managed window around w, or nil when it is not managed
+func For(w fyne.Window) *Window {
+	if current == nil {
+		return nil
+	}
+	return current.Find(w)
+}
+
+// Open starts app in a new window, or brings it back when it is single instance and running
+func (m *Manager) Open(app *registry.App) *Window {
+	if app.SingleInstance {
+		if running := m.Running(app.ID); len(running) > 0 {
+			running[0].Restore()
+			return running[0]
+		}
+	}
+	return m.OpenWith(app, func(w fyne.Window) fyne.CanvasObject {
+		return app.New(m.app, w)
+	})
+}
+
+// OpenFile starts app in a new window with the file at uri, apps without a
+// file handler are just opened
+func (m *Manager) OpenFile(app *registry.App, uri fyne.URI) *Window {
+	if app.OpenFile == nil {
+		return m.Open(app)
+	}
+	if app.SingleInstance {
+		for _, win := range m.Running(app.ID) {
+			win.Close()
+		}
+	}
+	return m.OpenWith(app, func(w fyne.Window) fyne.CanvasObject {
+		return app.OpenFile(m.app, w, uri)
+	})
+}
+
+// OpenWith starts app in a new window whose content is built by content
+func (m *Manager) OpenWith(app *registry.App, content func(w fyne.Window) fyne.CanvasObject) *Window {
+	m.mu.Lock()
+	m.nextID++
+	win := &Window{ID: m.nextID, App: app, Opened: time.Now(), manager: m}
+	m.windows = append(m.windows, win)
+	m.mu.Unlock()
+
+	w := m.app.NewWindow(app.Name)
+	win.Window = w
+	w.SetIcon(app.Icon)
+	w.SetContent(content(w))
+	w.SetCloseIntercept(win.Close)
+	w.Show()
+	m.setFocused(win)
+	return win
+}
+
+// Windows returns the open windows
+func (m *Manager) Windows() []*Window {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Window(nil), m.windows...)
+}
+
+// Running returns the open windows of one app
+func (m *Manager) Running(appID string) []*Window {
+	list := make([]*Window, 0)
+	for _, win := range m.Windows() {
+		if win.App.ID == appID {
+			list = append(list, win)
+		}
+	}
+	return list
+}
+
+// Find returns the managed window around w
+func (m *Manager) Find(w fyne.Window) *Window {
+	for _, win := range m.Windows() {
+		if win.Window == w {
+			return win
+		}
+	}
+	return nil
+}
+
+// Focused returns the window that was last focused
+func (m *Manager) Focused() *Window {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.focused
+}
+
+// OnChanged registers f to run whenever a window opens, closes or changes state
+func (m *Manager) OnChanged(f func()) {
+	m.mu.Lock()
+	m.listeners = append(m.listeners, f)
+	m.mu.Unlock()
+}
+
+// CloseAll closes every open window
+func (m *Manager) CloseAll() {
+	for _, win := range m.Windows() {
+		win.Close()
+	}
+}
+
+func (m *Manager) setFocused(win *Window) {
+	m.mu.Lock()
+	m.focused = win
+	m.mu.Unlock()
+	m.changed()
+}
+
+func (m *Manager) remove(win *Window) {
+	m.mu.Lock()
+	for i, w := range m.windows {
+		if w == win {
+			m.windows = append(m.windows[:i], m.windows[i+1:]...)
+			break
+		}
+	}
+	if m.focused == win {
+		m.focused = nil
+	}
+	m.mu.Unlock()
+	m.changed()
+}
+
+func (m *Manager) changed() {
+	m.mu.Lock()
+	listeners := append([]func(){}, m.listeners...)
+	m.mu.Unlock()
+	for _, f := range listeners {
+		f()
+	}
+}
+
+// Title returns the window title
+func (w *Window) Title() string {
+	return w.Window.Title()
+}
+
+// SetTitle changes the window title, the taskbar follows it
+func (w *Window) SetTitle(title string) {
+	w.Window.SetTitle(title)
+	w.manager.changed()
+}
+
+// Minimized reports if the window is hidden in the taskbar
+func (w *Window) Minimized() bool {
+	return w.minimized
+}
+
+// Focused reports if the window was the last one focused
+func (w *Window) Focused() bool {
+	return w.manager.Focused() == w
+}
+
+// Focus raises the window above the others
+func (w *Window) Focus() {
+	if w.minimized {
+		w.Restore()
+		return
+	}
+	w.Window.RequestFocus()
+	w.manager.setFocused(w)
+}
+
+// Minimize hides the window, it stays in the taskbar
+func (w *Window) Minimize() {
+	w.minimized = true
+	w.Window.Hide()
+	if w.Focused() {
+		w.manager.setFocused(nil)
+		return
+	}
+	w.manager.changed()
+}
+
+// Restore shows a minimized window again and focuses it
+func (w *Window) Restore() {
+	w.minimized = false
+	w.Window.Show()
+	w.Window.RequestFocus()
+	w.manager.setFocused(w)
+}
+
+// Close closes the window and forgets it
+func (w *Window) Close() {
+	w.manager.remove(w)
+	w.Window.Close()
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"weather"
+
+	"fyne.io/fyne/v2/app"
+)
+
+func main() {
+	a := app.NewWithID("io.varos.weather")
+	w := a.NewWindow("Weather")
+	w.SetContent(weather.New(a, w))
+	w.ShowAndRun()
+}
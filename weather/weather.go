@@ -1,33 +1,53 @@
-package main
+package weather
 
 import (
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 
 	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
+	"varos/notify"
+	"varos/registry"
+	"varos/session"
+	"varos/ui"
 )
 
-func main() {
-	app := app.New().NewWindow("Weather")
+//go:embed manifest.json
+var manifest []byte
+
+func init() {
+	registry.MustRegister(manifest, New)
+}
+
+// New builds the weather app inside w and returns its content
+func New(a fyne.App, w fyne.Window) fyne.CanvasObject {
 	cityList := [][2]string{
 		{"Delhi", "delhi"},
 		{"Noida", "noida"},
 		{"Mumbai", "mumbai"}}
-	app.SetContent(makeListTab(cityList, app))
-	app.Resize(fyne.Size{Height: 400, Width: 580})
-	app.ShowAndRun()
+	w.Resize(fyne.Size{Height: 400, Width: 580})
+	state := &weatherState{City: ""}
+	session.Restore(w, state)
+	session.Track(w, func() interface{} { return state })
+	return makeListTab(cityList, w, state)
+}
+
+// weatherState is the city a weather window shows, it is kept in the VarOS session
+type weatherState struct {
+	City string
 }
 
 type WeatherInfo struct {
+	name           string
+	condition      string
+	conditionID    int64
 	cordinates     [2]float64
 	temperature    float64
 	minTemperature float64
@@ -44,21 +64,26 @@ func DegToCard(d float64) string {
 	return dirs[int64((d+11.25)/22.5)%16]
 }
 
-func WeatherInfoFilter(city string) WeatherInfo {
+func WeatherInfoFilter(city string) (WeatherInfo, error) {
+	var info WeatherInfo
 	response, err := http.Get("https://api.openweathermap.org/data/2.5/weather?q=" + city + "&APPID=8bd4d6a9a95aadd819800ea99b951a8e")
 	if err != nil {
-		log.Panicln(err)
+		return info, err
 	}
 	defer response.Body.Close()
 	body, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		log.Panicln(err)
+		return info, err
 	}
 	weather, err := UnmarshalWelcome(body)
 	if err != nil {
-		log.Panicln(err)
+		return info, err
+	}
+	info.name = weather.Name
+	if len(weather.Weather) > 0 {
+		info.condition = weather.Weather[0].Description
+		info.conditionID = weather.Weather[0].ID
 	}
-	var info WeatherInfo
 	info.temperature = weather.Main.Temp - 273.15
 	info.minTemperature = weather.Main.TempMin - 273.15
 	info.maxTemperature = weather.Main.TempMax - 273.15
@@ -68,7 +93,26 @@ func WeatherInfoFilter(city string) WeatherInfo {
 	info.visibility = float64(weather.Visibility / 1000)
 	info.windspeed = weather.Wind.Speed
 	info.winddir = DegToCard(float64(weather.Wind.Deg))
-	return info
+	return info, nil
+}
+
+// WeatherAlert returns a warning for severe weather, or "" when there is none
+func WeatherAlert(info WeatherInfo) string {
+	switch {
+	case info.conditionID >= 200 && info.conditionID < 300:
+		return "Thunderstorm: " + info.condition
+	case info.conditionID >= 600 && info.conditionID < 700:
+		return "Snow: " + info.condition
+	case info.conditionID == 781:
+		return "Tornado warning"
+	case info.temperature >= 40:
+		return fmt.Sprintf("Heat warning, %.1f°C", info.temperature)
+	case info.temperature <= 0:
+		return fmt.Sprintf("Frost warning, %.1f°C", info.temperature)
+	case info.windspeed >= 17:
+		return fmt.Sprintf("Gale warning, wind %.1fm/s", info.windspeed)
+	}
+	return ""
 }
 
 func SelectCity(callback func(string)) *widget.Select {
@@ -79,8 +123,14 @@ func SelectCity(callback func(string)) *widget.Select {
 	return options
 }
 
-func DisplayLayout(city string) *fyne.Container {
-	weather := WeatherInfoFilter(city)
+func DisplayLayout(city string) (*fyne.Container, error) {
+	weather, err := WeatherInfoFilter(city)
+	if err != nil {
+		return nil, err
+	}
+	if alert := WeatherAlert(weather); alert != "" {
+		notify.Info("Weather", weather.name, alert)
+	}
 	cordinates := [2]string{fmt.Sprintf("%.2f° N", weather.cordinates[0]), fmt.Sprintf("%.2f° E", weather.cordinates[1])}
 	liveTemp := widget.NewLabel(fmt.Sprintf("%.2f°C", weather.temperature))
 	maxTemp := widget.NewLabel(fmt.Sprintf("%.2f°C", weather.maxTemperature))
@@ -117,7 +167,7 @@ func DisplayLayout(city string) *fyne.Container {
 				container.NewCenter(wind),
 			),
 		),
-	)
+	), nil
 }
 
 func UnmarshalWelcome(data []byte) (Welcome, error) {
@@ -184,10 +234,10 @@ type Wind struct {
 	Deg   int64   `json:"deg"`
 }
 
-func makeListTab(data [][2]string, app fyne.Window) fyne.CanvasObject {
+func makeListTab(data [][2]string, app fyne.Window, state *weatherState) fyne.CanvasObject {
 	icon := widget.NewIcon(theme.MenuIcon())
 	label := widget.NewLabel("Select a city from the menu")
-	hbox := container.NewVBox(container.NewHBox(icon, label), layout.NewSpacer())
+	hbox := container.NewVBox(ui.Toolbar(icon, label), layout.NewSpacer())
 
 	list := widget.NewList(
 		func() int {
@@ -201,16 +251,30 @@ func makeListTab(data [][2]string, app fyne.Window) fyne.CanvasObject {
 		},
 	)
 	list.OnSelected = func(id widget.ListItemID) {
+		state.City = data[id][1]
 		label.SetText(data[id][0])
 		icon.SetResource(theme.NavigateNextIcon())
 		hbox.Objects = hbox.Objects[0:1]
-		hbox.AddObject(DisplayLayout(data[id][1]))
-		app.Content().Refresh()
+		display, err := DisplayLayout(data[id][1])
+		if err != nil {
+			ui.ShowError(err, app)
+			return
+		}
+		hbox.AddObject(display)
+		if app.Content() != nil {
+			app.Content().Refresh()
+		}
 	}
 	list.OnUnselected = func(id widget.ListItemID) {
+		state.City = ""
 		label.SetText("Select An Item From The List")
 		icon.SetResource(nil)
 	}
+	for id, city := range data {
+		if city[1] == state.City {
+			list.Select(id)
+		}
+	}
 
 	r := container.NewHSplit(list, hbox)
 	r.Offset = 0